@@ -18,6 +18,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,6 +27,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/gluon/async"
@@ -184,6 +186,7 @@ func run(c *cli.Context) error {
 	identifier := useragent.New()
 
 	// Create a new Sentry client that will be used to report crashes etc.
+	sentry.Enable()
 	reporter := sentry.NewReporter(constants.FullAppName, identifier)
 
 	// Determine the exe that should be used to restart/autostart the app.
@@ -204,6 +207,16 @@ func run(c *cli.Context) error {
 		_ = logging.Close(logCloser)
 	}()
 
+	// Give pending crash reports a chance to be delivered before exiting.
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := reporter.Close(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to deliver pending reports")
+		}
+	}()
+
 	// Restart the app if requested.
 	err = withRestarter(exe, func(restarter *restarter.Restarter) error {
 		// Handle crashes with various actions.
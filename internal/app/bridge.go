@@ -71,16 +71,19 @@ func withBridge(
 		}
 	}
 
+	// The options of all transports used to talk to the API.
+	transportOptions := dialer.DefaultTransportOptions()
+
 	// Create the underlying dialer used by the bridge.
 	// It only connects to trusted servers and reports any untrusted servers it finds.
 	pinningDialer := dialer.NewPinningTLSDialer(
-		dialer.NewBasicTLSDialer(constants.APIHost),
+		dialer.NewBasicTLSDialerWithOptions(constants.APIHost, transportOptions),
 		dialer.NewTLSReporter(constants.APIHost, constants.AppVersion(version.Original()), identifier, dialer.TrustedAPIPins),
 		dialer.NewTLSPinChecker(dialer.TrustedAPIPins),
 	)
 
 	// Create a proxy dialer which switches to a proxy if the request fails.
-	proxyDialer := dialer.NewProxyTLSDialer(pinningDialer, constants.APIHost, crashHandler)
+	proxyDialer := dialer.NewProxyTLSDialerWithOptions(pinningDialer, constants.APIHost, crashHandler, transportOptions)
 
 	// Create the autostarter.
 	autostarter := newAutostarter(exe)
@@ -106,7 +109,7 @@ func withBridge(
 		cookieJar,
 		identifier,
 		pinningDialer,
-		dialer.CreateTransportWithDialer(proxyDialer),
+		dialer.CreateTransportWithDialerAndOptions(proxyDialer, transportOptions),
 		proxyDialer,
 
 		// Crash and report stuff
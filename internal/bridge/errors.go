@@ -28,6 +28,7 @@ var (
 	ErrUserAlreadyExists   = errors.New("user already exists")
 	ErrUserAlreadyLoggedIn = errors.New("the user is already logged in")
 	ErrNotImplemented      = errors.New("not implemented")
+	ErrInvalidTOTPCode     = errors.New("code must be 6 digits")
 
 	ErrSizeTooLarge = errors.New("file is too big")
 )
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTOTPCode(t *testing.T) {
+	require.NoError(t, bridge.ValidateTOTPCode("123456"))
+	require.NoError(t, bridge.ValidateTOTPCode("000000"))
+
+	for _, code := range []string{"", "12345", "1234567", "12345a", "12 345", "١٢٣٤٥٦"} {
+		require.ErrorIs(t, bridge.ValidateTOTPCode(code), bridge.ErrInvalidTOTPCode, code)
+	}
+}
+
+func TestGenerateTOTP(t *testing.T) {
+	// The SHA1 test vectors of RFC 6238 appendix B; the secret is the base32 encoding of "12345678901234567890".
+	// The RFC lists 8 digit codes, the 6 digit codes are their last 6 digits.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := bridge.GenerateTOTP(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, want, code, unix)
+		require.NoError(t, bridge.ValidateTOTPCode(code))
+	}
+}
+
+func TestGenerateTOTP_SecretFormat(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	// Secrets are often shown lowercase, grouped and padded.
+	code, err := bridge.GenerateTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", now)
+	require.NoError(t, err)
+	require.Equal(t, "081804", code)
+
+	_, err = bridge.GenerateTOTP("not base32!", now)
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Channel is the update channel the app is following.
+type Channel string
+
+const (
+	ChannelStable Channel = "stable"
+	ChannelBeta   Channel = "beta"
+)
+
+// AppVersionForChannel is like AppVersion but marks non-stable channels in the prerelease segment,
+// so that a beta version sorts before the stable version with the same base.
+func AppVersionForChannel(version string, ch Channel) string {
+	if ch == ChannelStable {
+		return AppVersion(version)
+	}
+
+	return AppVersion(appendPrerelease(version, string(ch)))
+}
+
+// appendPrerelease adds identifier to the prerelease segment of version unless it is already present.
+// Unparsable versions are returned unchanged.
+func appendPrerelease(version, identifier string) string {
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return version
+	}
+
+	prerelease := joinPrerelease(ver.Prerelease(), identifier)
+
+	withPrerelease, err := ver.SetPrerelease(prerelease)
+	if err != nil {
+		return version
+	}
+
+	return withPrerelease.String()
+}
+
+func joinPrerelease(prerelease, identifier string) string {
+	if prerelease == "" {
+		return identifier
+	}
+
+	for _, part := range strings.Split(prerelease, ".") {
+		if part == identifier {
+			return prerelease
+		}
+	}
+
+	return prerelease + "." + identifier
+}
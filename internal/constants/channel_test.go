@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendPrerelease(t *testing.T) {
+	require.Equal(t, "2.3.0-beta", appendPrerelease("2.3.0", "beta"))
+	require.Equal(t, "2.3.0-dev.beta+qa", appendPrerelease("2.3.0-dev+qa", "beta"))
+	require.Equal(t, "2.3.0-beta", appendPrerelease("2.3.0-beta", "beta"))
+	require.Equal(t, "garbage", appendPrerelease("garbage", "beta"))
+}
+
+func TestChannelOrdering(t *testing.T) {
+	stable := semver.MustParse("2.3.0")
+	beta := semver.MustParse(appendPrerelease("2.3.0", string(ChannelBeta)))
+
+	require.True(t, beta.LessThan(stable))
+}
+
+func TestAppVersionForChannel(t *testing.T) {
+	require.Equal(t, AppVersion("2.3.0"), AppVersionForChannel("2.3.0", ChannelStable))
+	require.Contains(t, AppVersionForChannel("2.3.0", ChannelBeta), "beta")
+}
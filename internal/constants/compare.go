@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// IsNewer returns whether candidate is a newer version than current.
+// Prerelease versions are older than the release with the same base, e.g. 2.0.0-dev < 2.0.0.
+func IsNewer(current, candidate string) (bool, error) {
+	res, err := compareVersions(candidate, current)
+	if err != nil {
+		return false, err
+	}
+
+	return res > 0, nil
+}
+
+// MustCompare returns -1, 0 or 1 when a is older than, equal to or newer than b. It panics on invalid input.
+func MustCompare(a, b string) int {
+	res, err := compareVersions(a, b)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+func compareVersions(a, b string) (int, error) {
+	verA, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q: %w", a, err)
+	}
+
+	verB, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q: %w", b, err)
+	}
+
+	return verA.Compare(verB), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNewer(t *testing.T) {
+	for _, tt := range []struct {
+		current, candidate string
+		want               bool
+	}{
+		{"2.0.0", "2.0.1", true},
+		{"2.0.1", "2.0.0", false},
+		{"2.0.0", "2.0.0", false},
+		{"2.0.0-dev", "2.0.0", true},
+		{"2.0.0", "2.0.0-dev", false},
+		{"2.0.0-beta", "2.0.0-dev", true},
+	} {
+		newer, err := IsNewer(tt.current, tt.candidate)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, newer, "%v -> %v", tt.current, tt.candidate)
+	}
+}
+
+func TestIsNewer_Invalid(t *testing.T) {
+	_, err := IsNewer("garbage", "2.0.0")
+	require.ErrorContains(t, err, "garbage")
+
+	_, err = IsNewer("2.0.0", "")
+	require.Error(t, err)
+}
+
+func TestMustCompare(t *testing.T) {
+	require.Equal(t, -1, MustCompare("2.0.0-dev", "2.0.0"))
+	require.Equal(t, 0, MustCompare("2.0.0", "2.0.0"))
+	require.Equal(t, 1, MustCompare("2.1.0", "2.0.0"))
+	require.Panics(t, func() { MustCompare("garbage", "2.0.0") })
+}
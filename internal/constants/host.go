@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidAPIHost is returned when an API host override is not usable.
+var ErrInvalidAPIHost = errors.New("API host must be an absolute https URL")
+
+// parseAPIHost validates an API host override and strips any trailing slash,
+// so that relative routes like "/auth/info" can be appended to it directly.
+func parseAPIHost(host string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "https" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", ErrInvalidAPIHost
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
@@ -19,13 +19,23 @@
 
 package constants
 
-import "os"
+import (
+	"os"
 
-// APIHost is our API address.
-var APIHost = "https://mail-api.proton.me"
+	"github.com/sirupsen/logrus"
+)
+
+// APIHost is our API address. QA builds talk to the test environment unless overridden.
+var APIHost = "https://api.proton.black"
 
 func init() {
 	if apiHost := os.Getenv("BRIDGE_HOST_URL"); apiHost != "" {
-		APIHost = apiHost
+		host, err := parseAPIHost(apiHost)
+		if err != nil {
+			logrus.WithError(err).WithField("host", apiHost).Error("Ignoring invalid API host override")
+			return
+		}
+
+		APIHost = host
 	}
 }
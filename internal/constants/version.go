@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package constants
+
+import (
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// unknownVersion is used in place of a version which cannot be parsed.
+const unknownVersion = "0.0.0-unknown"
+
+var logInvalidVersionOnce sync.Once //nolint:gochecknoglobals
+
+// parseVersion parses version and falls back to unknownVersion if it is malformed,
+// so a bad embedded version never crashes the app. The failure is logged only once.
+func parseVersion(version string) *semver.Version {
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		logInvalidVersionOnce.Do(func() {
+			logrus.WithError(err).WithField("version", version).Error("Failed to parse app version")
+		})
+
+		return semver.MustParse(unknownVersion)
+	}
+
+	return ver
+}
@@ -20,15 +20,12 @@
 
 package constants
 
-import (
-	"fmt"
-
-	"github.com/Masterminds/semver/v3"
-)
+import "fmt"
 
 // AppVersion returns the full rendered version of the app (to be used in request headers).
 func AppVersion(version string) string {
-	ver, _ := semver.MustParse(version).SetPrerelease("dev")
+	parsed := parseVersion(version)
+	ver, _ := parsed.SetPrerelease(joinPrerelease(parsed.Prerelease(), "dev"))
 
 	return fmt.Sprintf("%v-%v@%v", getAPIOS(), AppName, ver.String())
 }
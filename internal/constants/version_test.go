@@ -35,3 +35,17 @@ func TestPrereleaseSemver(t *testing.T) {
 
 	require.Equal(t, "2.3.0-dev+qa", ver.String())
 }
+
+func TestParseVersion(t *testing.T) {
+	require.Equal(t, "2.3.0+qa", parseVersion("2.3.0+qa").String())
+	require.Equal(t, unknownVersion, parseVersion("").String())
+	require.Equal(t, unknownVersion, parseVersion("garbage").String())
+}
+
+func TestAppVersion_BadInput(t *testing.T) {
+	for _, version := range []string{"", "garbage", "2.3.0"} {
+		require.NotPanics(t, func() { AppVersion(version) })
+	}
+
+	require.Contains(t, AppVersion("2.3.0"), "@2.3.0")
+}
@@ -29,15 +29,48 @@ type TLSDialer interface {
 	DialTLSContext(ctx context.Context, network, address string) (conn net.Conn, err error)
 }
 
+// TransportOptions tunes how connections to the API are kept and reused.
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per host.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept before it is closed.
+	IdleConnTimeout time.Duration
+
+	// ForceAttemptHTTP2 enables HTTP/2 even though a custom dialer is used.
+	// It only takes effect if the dialer negotiates h2 during the TLS handshake,
+	// see NewBasicTLSDialerWithOptions.
+	ForceAttemptHTTP2 bool
+}
+
+// DefaultTransportOptions returns the options used by CreateTransportWithDialer.
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     5 * time.Minute,
+	}
+}
+
 // CreateTransportWithDialer creates an http.Transport that uses the given dialer to make TLS connections.
 func CreateTransportWithDialer(dialer TLSDialer) *http.Transport {
+	return CreateTransportWithDialerAndOptions(dialer, DefaultTransportOptions())
+}
+
+// CreateTransportWithDialerAndOptions creates an http.Transport that uses the given dialer to make TLS connections
+// and keeps idle connections according to the given options.
+func CreateTransportWithDialerAndOptions(dialer TLSDialer, opts TransportOptions) *http.Transport {
 	return &http.Transport{
 		DialTLSContext: dialer.DialTLSContext,
 
 		Proxy:               http.ProxyFromEnvironment,
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     5 * time.Minute,
+		MaxIdleConns:        opts.MaxIdleConns,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		ForceAttemptHTTP2:   opts.ForceAttemptHTTP2,
 
 		ExpectContinueTimeout: 500 * time.Millisecond,
 
@@ -54,14 +87,27 @@ func CreateTransportWithDialer(dialer TLSDialer) *http.Transport {
 
 // BasicTLSDialer implements TLSDialer.
 type BasicTLSDialer struct {
-	hostURL string
+	hostURL    string
+	nextProtos []string
 }
 
 // NewBasicTLSDialer returns a new BasicTLSDialer.
 func NewBasicTLSDialer(hostURL string) *BasicTLSDialer {
-	return &BasicTLSDialer{
+	return NewBasicTLSDialerWithOptions(hostURL, DefaultTransportOptions())
+}
+
+// NewBasicTLSDialerWithOptions returns a new BasicTLSDialer which offers h2 during the TLS handshake
+// if the given options ask for HTTP/2.
+func NewBasicTLSDialerWithOptions(hostURL string, opts TransportOptions) *BasicTLSDialer {
+	dialer := &BasicTLSDialer{
 		hostURL: hostURL,
 	}
+
+	if opts.ForceAttemptHTTP2 {
+		dialer.nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	return dialer
 }
 
 // DialTLSContext returns a connection to the given address using the given network.
@@ -72,6 +118,7 @@ func (d *BasicTLSDialer) DialTLSContext(ctx context.Context, network, address st
 		},
 		Config: &tls.Config{
 			InsecureSkipVerify: address != d.hostURL, //nolint:gosec
+			NextProtos:         d.nextProtos,
 		},
 	}).DialContext(ctx, network, address)
 }
@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package dialer
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingDialer counts the connections opened by the wrapped dialer.
+type countingDialer struct {
+	TLSDialer
+
+	dials atomic.Int32
+}
+
+func (d *countingDialer) DialTLSContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials.Add(1)
+
+	return d.TLSDialer.DialTLSContext(ctx, network, address)
+}
+
+func TestTransportReusesConnections(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dialer := &countingDialer{TLSDialer: NewBasicTLSDialer("")}
+	client := &http.Client{Transport: CreateTransportWithDialerAndOptions(dialer, DefaultTransportOptions())}
+
+	// Like the login sequence, which hits several auth routes one after another.
+	for _, route := range []string{"/auth/info", "/auth", "/auth/2fa"} {
+		res, err := client.Get(server.URL + route)
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+
+	require.Equal(t, int32(1), dialer.dials.Load())
+}
+
+func TestTransportOptions(t *testing.T) {
+	transport := CreateTransportWithDialerAndOptions(NewBasicTLSDialer(""), TransportOptions{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     time.Minute,
+		ForceAttemptHTTP2:   true,
+	})
+
+	require.Equal(t, 10, transport.MaxIdleConns)
+	require.Equal(t, 2, transport.MaxIdleConnsPerHost)
+	require.Equal(t, time.Minute, transport.IdleConnTimeout)
+	require.True(t, transport.ForceAttemptHTTP2)
+}
+
+func TestTransportNegotiatesHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, forceHTTP2 := range []bool{false, true} {
+		opts := DefaultTransportOptions()
+		opts.ForceAttemptHTTP2 = forceHTTP2
+
+		client := &http.Client{Transport: CreateTransportWithDialerAndOptions(NewBasicTLSDialerWithOptions("", opts), opts)}
+
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		if forceHTTP2 {
+			require.Equal(t, 2, res.ProtoMajor)
+		} else {
+			require.Equal(t, 1, res.ProtoMajor)
+		}
+	}
+}
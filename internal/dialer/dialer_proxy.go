@@ -47,12 +47,25 @@ type ProxyTLSDialer struct {
 
 // NewProxyTLSDialer constructs a dialer which provides a proxy-managing layer on top of an underlying dialer.
 func NewProxyTLSDialer(dialer TLSDialer, hostURL string, panicHandler async.PanicHandler) *ProxyTLSDialer {
+	return NewProxyTLSDialerWithOptions(dialer, hostURL, panicHandler, DefaultTransportOptions())
+}
+
+// NewProxyTLSDialerWithOptions constructs a ProxyTLSDialer whose proxy checks use transports with the given options.
+func NewProxyTLSDialerWithOptions(
+	dialer TLSDialer,
+	hostURL string,
+	panicHandler async.PanicHandler,
+	opts TransportOptions,
+) *ProxyTLSDialer {
+	provider := newProxyProvider(dialer, hostURL, DoHProviders, panicHandler)
+	provider.transportOptions = opts
+
 	return &ProxyTLSDialer{
 		dialer:           dialer,
 		locker:           sync.RWMutex{},
 		directAddress:    formatAsAddress(hostURL),
 		proxyAddress:     formatAsAddress(hostURL),
-		proxyProvider:    newProxyProvider(dialer, hostURL, DoHProviders, panicHandler),
+		proxyProvider:    provider,
 		proxyUseDuration: proxyUseDuration,
 		panicHandler:     panicHandler,
 	}
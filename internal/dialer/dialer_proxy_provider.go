@@ -69,6 +69,8 @@ type proxyProvider struct {
 
 	lastLookup time.Time // The time at which we last attempted to find a proxy.
 
+	transportOptions TransportOptions // Options of the transport used to check whether a proxy is reachable.
+
 	panicHandler async.PanicHandler
 }
 
@@ -83,6 +85,7 @@ func newProxyProvider(dialer TLSDialer, hostURL string, providers []string, pani
 		cacheRefreshTimeout: proxyCacheRefreshTimeout,
 		dohTimeout:          proxyDoHTimeout,
 		canReachTimeout:     proxyCanReachTimeout,
+		transportOptions:    DefaultTransportOptions(),
 		panicHandler:        panicHandler,
 	}
 
@@ -192,7 +195,7 @@ func (p *proxyProvider) canReach(url string) bool {
 	pinger := resty.New().
 		SetBaseURL(url).
 		SetTimeout(p.canReachTimeout).
-		SetTransport(CreateTransportWithDialer(p.dialer))
+		SetTransport(CreateTransportWithDialerAndOptions(p.dialer, p.transportOptions))
 
 	if _, err := pinger.R().Get("/tests/ping"); err != nil {
 		logrus.WithField("proxy", url).WithError(err).Warn("Failed to ping proxy")
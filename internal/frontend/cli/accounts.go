@@ -157,6 +157,11 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) {
 			return
 		}
 
+		// Recovery codes are accepted too, so a code which is not a TOTP code is only a hint.
+		if err := bridge.ValidateTOTPCode(code); err != nil {
+			f.Println("Not a TOTP code (" + err.Error() + "), trying it as a recovery code ... ")
+		}
+
 		if err := client.Auth2FA(context.Background(), proton.Auth2FAReq{TwoFactorCode: code}); err != nil {
 			f.printAndLogError("Cannot login: ", err)
 			return
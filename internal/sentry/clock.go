@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import "time"
+
+// Clock provides the current time and timers to the reporter.
+type Clock interface {
+	Now() time.Time
+
+	// After sends the current time on the returned channel once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SetClock replaces the clock used by the reporter, e.g. to make deduplication, queued event names,
+// report slot timeouts and sampling deterministic in tests. The sampler is reseeded from the new clock.
+// Flush timeouts are handed to the sentry client, which measures them with the real clock.
+func (r *Reporter) SetClock(clock Clock) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clock = clock
+	r.sampler.reseed(clock.Now().UnixNano())
+}
+
+func (r *Reporter) now() time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.clock.Now()
+}
+
+func (r *Reporter) after(d time.Duration) <-chan time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.clock.After(d)
+}
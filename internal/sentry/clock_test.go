@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	r "github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now   time.Time
+	after chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// After returns the clock's after channel, so tests decide when timers fire.
+func (c *fakeClock) After(time.Duration) <-chan time.Time {
+	return c.after
+}
+
+func TestReporter_DedupWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	reporter := newDryRunReporter(t)
+	reporter.SetClock(clock)
+	reporter.SetDedupWindow(time.Minute)
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.Len(t, reporter.CapturedEvents(), 1)
+
+	clock.now = clock.now.Add(time.Minute)
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 2)
+	r.Equal(t, "1", events[1].Contexts["bridge"]["suppressed"])
+}
+
+func TestReporter_SlotTimeoutWithClock(t *testing.T) {
+	clock := &fakeClock{after: make(chan time.Time, 1)}
+
+	reporter := newDryRunReporter(t)
+	reporter.SetClock(clock)
+
+	for i := 0; i < maxConcurrentReports; i++ {
+		r.True(t, reporter.acquireReport(false))
+	}
+
+	// The slot timeout elapses as soon as the clock says so.
+	clock.after <- clock.now
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.Empty(t, reporter.CapturedEvents())
+	r.Equal(t, int64(1), reporter.droppedReports.Load())
+
+	for i := 0; i < maxConcurrentReports; i++ {
+		reporter.releaseReport()
+	}
+}
+
+func TestEventQueue_NamesUseClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 42)}
+
+	dir := t.TempDir()
+
+	queue, err := newEventQueue(dir, clock.Now)
+	r.NoError(t, err)
+	r.NoError(t, queue.push(&sentry.Event{EventID: "id"}))
+
+	entries, err := os.ReadDir(dir)
+	r.NoError(t, err)
+	r.Len(t, entries, 1)
+	r.True(t, strings.HasPrefix(entries[0].Name(), "00000000000000000042-id"), entries[0].Name())
+	r.Equal(t, queuedEventExt, filepath.Ext(entries[0].Name()))
+}
+
+func TestReporter_SetClockReseedsSampler(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 1)}
+
+	a, b := newDryRunReporter(t), newDryRunReporter(t)
+	a.SetClock(clock)
+	b.SetClock(clock)
+
+	for i := 0; i < 100; i++ {
+		r.Equal(t, a.sampler.sample(0.5), b.sampler.sample(0.5))
+	}
+}
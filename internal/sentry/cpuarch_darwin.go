@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build darwin
+// +build darwin
+
+package sentry
+
+import (
+	"github.com/elastic/go-sysinfo"
+	"golang.org/x/sys/unix"
+)
+
+const arm64OptionalDarwin = "hw.optional.arm64"
+
+// getCPUArch returns the physical CPU architecture, which differs from the
+// binary architecture when an amd64 binary runs on Apple silicon via rosetta.
+func getCPUArch() string {
+	if res, err := unix.SysctlUint32(arm64OptionalDarwin); err == nil && res == 1 {
+		return "arm64"
+	}
+
+	host, err := sysinfo.Host()
+	if err != nil {
+		return "not-detected"
+	}
+
+	return host.Info().Architecture
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+// +build linux
+
+package sentry
+
+import (
+	"os"
+	"strings"
+)
+
+// getCPUArch returns the physical CPU architecture as described by /proc/cpuinfo.
+func getCPUArch() string {
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return getHostArch()
+	}
+
+	if arch := cpuArchFromCPUInfo(string(cpuinfo)); arch != "" {
+		return arch
+	}
+
+	return getHostArch()
+}
+
+func cpuArchFromCPUInfo(cpuinfo string) string {
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "flags":
+			// The lm (long mode) flag marks 64-bit x86 CPUs.
+			for _, flag := range strings.Fields(value) {
+				if flag == "lm" {
+					return "x86_64"
+				}
+			}
+
+			return "i686"
+
+		case "CPU architecture":
+			if value == "8" {
+				return "aarch64"
+			}
+
+			return "armv" + value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+// +build linux
+
+package sentry
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestCPUArchFromCPUInfo(t *testing.T) {
+	r.Equal(t, "x86_64", cpuArchFromCPUInfo("processor\t: 0\nvendor_id\t: GenuineIntel\nflags\t\t: fpu vme lm sse\n"))
+	r.Equal(t, "i686", cpuArchFromCPUInfo("processor\t: 0\nflags\t\t: fpu vme sse\n"))
+	r.Equal(t, "aarch64", cpuArchFromCPUInfo("processor\t: 0\nFeatures\t: fp asimd\nCPU architecture: 8\n"))
+	r.Equal(t, "armv7", cpuArchFromCPUInfo("processor\t: 0\nCPU architecture: 7\n"))
+	r.Equal(t, "", cpuArchFromCPUInfo(""))
+}
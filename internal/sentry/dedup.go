@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
+)
+
+const (
+	// defaultDedupWindow is the interval in which identical messages are reported only once.
+	defaultDedupWindow = time.Minute
+
+	// maxDedupEntries bounds the number of messages remembered by the deduplicator.
+	maxDedupEntries = 256
+)
+
+type dedupEntry struct {
+	key        string
+	lastSent   time.Time
+	suppressed int
+}
+
+// messageDeduper suppresses identical messages reported within a time window.
+// The last sent times are kept in a bounded LRU so memory stays flat.
+type messageDeduper struct {
+	lock    sync.Mutex
+	window  time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func newMessageDeduper(window time.Duration) *messageDeduper {
+	return &messageDeduper{
+		window:  window,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (d *messageDeduper) setWindow(window time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.window = window
+}
+
+// allow returns whether the message with the given key should be sent now,
+// and how many duplicates were suppressed since it was last sent.
+func (d *messageDeduper) allow(key string, now time.Time) (bool, int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.window <= 0 {
+		return true, 0
+	}
+
+	if elem, ok := d.entries[key]; ok {
+		entry := elem.Value.(*dedupEntry) //nolint:forcetypeassert
+
+		d.order.MoveToFront(elem)
+
+		if now.Sub(entry.lastSent) < d.window {
+			entry.suppressed++
+			return false, 0
+		}
+
+		suppressed := entry.suppressed
+
+		entry.lastSent = now
+		entry.suppressed = 0
+
+		return true, suppressed
+	}
+
+	d.entries[key] = d.order.PushFront(&dedupEntry{key: key, lastSent: now})
+
+	for d.order.Len() > maxDedupEntries {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key) //nolint:forcetypeassert
+	}
+
+	return true, 0
+}
+
+// messageKey returns a hash of the message and its context which does not depend on map ordering.
+func messageKey(msg string, context map[string]interface{}) string {
+	keys := make([]string, 0, len(context))
+	for k := range context {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+
+	b.WriteString(msg)
+
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s=%v", k, context[k])
+	}
+
+	return algo.HashHexSHA256(b.String())
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMessageDeduper(t *testing.T) {
+	deduper := newMessageDeduper(time.Minute)
+	now := time.Now()
+
+	send, suppressed := deduper.allow("key", now)
+	r.True(t, send)
+	r.Zero(t, suppressed)
+
+	for i := 0; i < 3; i++ {
+		send, _ = deduper.allow("key", now.Add(time.Second))
+		r.False(t, send)
+	}
+
+	send, _ = deduper.allow("other", now.Add(time.Second))
+	r.True(t, send)
+
+	send, suppressed = deduper.allow("key", now.Add(time.Minute))
+	r.True(t, send)
+	r.Equal(t, 3, suppressed)
+}
+
+func TestMessageDeduper_Disabled(t *testing.T) {
+	deduper := newMessageDeduper(0)
+
+	for i := 0; i < 3; i++ {
+		send, _ := deduper.allow("key", time.Now())
+		r.True(t, send)
+	}
+}
+
+func TestMessageDeduper_Bounded(t *testing.T) {
+	deduper := newMessageDeduper(time.Minute)
+
+	for i := 0; i < maxDedupEntries*2; i++ {
+		deduper.allow(fmt.Sprint(i), time.Now())
+	}
+
+	r.Len(t, deduper.entries, maxDedupEntries)
+	r.Equal(t, maxDedupEntries, deduper.order.Len())
+}
+
+func TestMessageKey(t *testing.T) {
+	first := messageKey("msg", map[string]interface{}{"a": 1, "b": "two"})
+	second := messageKey("msg", map[string]interface{}{"b": "two", "a": 1})
+
+	r.Equal(t, first, second)
+	r.NotEqual(t, first, messageKey("msg", map[string]interface{}{"a": 2, "b": "two"}))
+	r.NotEqual(t, first, messageKey("other", map[string]interface{}{"a": 1, "b": "two"}))
+}
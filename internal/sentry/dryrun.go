@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// dryRunSink is a separate sentry hub whose transport keeps the events in memory.
+type dryRunSink struct {
+	hub       *sentry.Hub
+	transport *memoryTransport
+}
+
+// SetDryRun makes the reporter assemble events as usual but keep them in memory
+// instead of sending them. The captured events are available from CapturedEvents.
+// Disabling dry run drops the captured events.
+func (r *Reporter) SetDryRun(dryRun bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !dryRun {
+		r.dryRun = nil
+		return
+	}
+
+	transport := &memoryTransport{}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: EnhanceSentryEvent,
+		Transport:  transport,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create dry run sentry client")
+		return
+	}
+
+	r.dryRun = &dryRunSink{
+		hub:       sentry.NewHub(client, sentry.NewScope()),
+		transport: transport,
+	}
+}
+
+// CapturedEvents returns the events captured in dry run mode.
+func (r *Reporter) CapturedEvents() []*sentry.Event {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.dryRun == nil {
+		return nil
+	}
+
+	return r.dryRun.transport.capturedEvents()
+}
+
+// memoryTransport keeps the sent events in memory.
+type memoryTransport struct {
+	lock   sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *memoryTransport) Flush(time.Duration) bool {
+	return true
+}
+
+func (t *memoryTransport) Configure(sentry.ClientOptions) {}
+
+func (t *memoryTransport) SendEvent(event *sentry.Event) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.events = append(t.events, event)
+}
+
+func (t *memoryTransport) capturedEvents() []*sentry.Event {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]*sentry.Event{}, t.events...)
+}
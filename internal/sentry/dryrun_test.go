@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/restarter"
+	"github.com/getsentry/sentry-go"
+	r "github.com/stretchr/testify/require"
+)
+
+func newDryRunReporter(t *testing.T) *Reporter {
+	t.Setenv("PROTONMAIL_ENV", "dev")
+
+	reporter := NewReporter("test", fakeIdentifier{})
+	reporter.SetDryRun(true)
+	reporter.SetDedupWindow(0)
+
+	return reporter
+}
+
+func TestDryRun_Message(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportMessageWithLevel("mail from "+testEmail, sentry.LevelWarning, map[string]interface{}{"count": 3}))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 1)
+
+	event := events[0]
+	r.Equal(t, "mail from "+redactedValue(testEmail), event.Message)
+	r.Equal(t, sentry.LevelWarning, event.Level)
+	r.Equal(t, "test", event.Tags["Client"])
+	r.Equal(t, "fake-agent", event.Tags["UserAgent"])
+	r.Equal(t, "3", event.Contexts["bridge"]["count"])
+}
+
+func TestDryRun_Exception(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportException("boom"))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 1)
+	r.Len(t, events[0].Exception, 1)
+}
+
+func TestDryRun_Disable(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.Len(t, reporter.CapturedEvents(), 1)
+
+	reporter.SetDryRun(false)
+	r.Empty(t, reporter.CapturedEvents())
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.Empty(t, reporter.CapturedEvents())
+}
+
+func TestDryRun_GoroutineDump(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportException("boom"))
+	r.NotContains(t, reporter.CapturedEvents()[0].Contexts["bridge"], "goroutines")
+
+	reporter.SetAttachGoroutineDump(true)
+
+	// Park enough goroutines for the dump to exceed the size cap of regular context values.
+	done := make(chan struct{})
+	defer close(done)
+
+	for i := 0; i < 100; i++ {
+		go func() { <-done }()
+	}
+
+	r.NoError(t, reporter.ReportException("boom"))
+
+	dump, ok := reporter.CapturedEvents()[1].Contexts["bridge"][goroutineDumpKey].(string)
+	r.True(t, ok)
+	r.Greater(t, len(dump), maxContextValueSize)
+	r.Greater(t, strings.Count(dump, "goroutine "), 100)
+	r.LessOrEqual(t, len(dump), maxGoroutineDumpSize+len("\n... truncated"))
+}
+
+func TestDryRun_Environment(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportMessage("msg"))
+	r.Equal(t, EnvironmentDev, reporter.CapturedEvents()[0].Tags["environment"])
+
+	reporter.SetEnvironment(EnvironmentQA)
+
+	r.NoError(t, reporter.ReportMessage("other"))
+	r.Equal(t, EnvironmentQA, reporter.CapturedEvents()[1].Tags["environment"])
+}
+
+func TestDryRun_Warning(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportWarning("sync-failed", "Failed to sync", nil))
+	r.NoError(t, reporter.ReportMessage("plain"))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 2)
+	r.Equal(t, sentry.LevelWarning, events[0].Level)
+	r.Equal(t, []string{"warning", "sync-failed"}, events[0].Fingerprint)
+	r.NotEqual(t, []string{"warning", "sync-failed"}, events[1].Fingerprint)
+}
+
+func TestDryRun_BuildTags(t *testing.T) {
+	buildTime, revision := constants.BuildTime, constants.Revision
+	defer func() { constants.BuildTime, constants.Revision = buildTime, revision }()
+
+	constants.BuildTime = "2023-05-04T12:00:00+0000"
+	constants.Revision = "abcdef0"
+	t.Setenv(restarter.BridgeCrashCount, "2")
+
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportException("boom"))
+	r.NoError(t, reporter.ReportMessage("message"))
+	r.Len(t, reporter.CapturedEvents(), 2)
+
+	for _, event := range reporter.CapturedEvents() {
+		r.Equal(t, "2023-05-04T12:00:00+0000", event.Tags["BuildTime"])
+		r.Equal(t, "abcdef0", event.Tags["Revision"])
+		r.Equal(t, "2", event.Tags["CrashCount"])
+	}
+
+	// The exception keeps the build metadata in its context too.
+	context := reporter.CapturedEvents()[0].Contexts["bridge"]
+	r.Equal(t, "2023-05-04T12:00:00+0000", context["build"])
+	r.Equal(t, "2", context["crash"])
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import "os"
+
+// Environments a report can be tagged with.
+const (
+	EnvironmentDev  = "dev"
+	EnvironmentQA   = "qa"
+	EnvironmentProd = "prod"
+)
+
+// environmentFromEnv derives the environment from PROTONMAIL_ENV, defaulting to production.
+func environmentFromEnv() string {
+	switch env := os.Getenv("PROTONMAIL_ENV"); env {
+	case EnvironmentDev, EnvironmentQA:
+		return env
+
+	default:
+		return EnvironmentProd
+	}
+}
+
+// SetEnvironment overrides the environment the reports are tagged with.
+func (r *Reporter) SetEnvironment(environment string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.environment = environment
+}
+
+// SetReportDevEnvironment enables sending reports from the dev environment, which are dropped by default.
+func (r *Reporter) SetReportDevEnvironment(report bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.reportDevEnvironment = report
+}
+
+// isDisabled reports whether reports are dropped anyway, so the report methods can return
+// before assembling anything.
+func (r *Reporter) isDisabled() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.dryRun == nil && r.environment == EnvironmentDev && !r.reportDevEnvironment
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"errors"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func newDisabledReporter() *Reporter {
+	reporter := NewReporter("test", fakeIdentifier{})
+	reporter.SetEnvironment(EnvironmentDev)
+
+	return reporter
+}
+
+func TestDisabledReporter_NoAllocations(t *testing.T) {
+	reporter := newDisabledReporter()
+	err := errors.New("boom")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = reporter.ReportException(err)
+		_ = reporter.ReportMessage("message")
+		_ = reporter.ReportMessageWithContext("message", nil)
+		_ = reporter.ReportWarning("key", "message", nil)
+	})
+
+	r.Zero(t, allocs)
+}
+
+func TestDisabledReporter_DevReportingEnabled(t *testing.T) {
+	reporter := newDisabledReporter()
+	r.True(t, reporter.isDisabled())
+
+	reporter.SetReportDevEnvironment(true)
+	r.False(t, reporter.isDisabled())
+
+	reporter.SetReportDevEnvironment(false)
+	reporter.SetDryRun(true)
+	r.False(t, reporter.isDisabled())
+}
+
+func TestEnvironmentFromEnv(t *testing.T) {
+	for env, want := range map[string]string{
+		"dev":  EnvironmentDev,
+		"qa":   EnvironmentQA,
+		"":     EnvironmentProd,
+		"prod": EnvironmentProd,
+	} {
+		t.Setenv("PROTONMAIL_ENV", env)
+		r.Equal(t, want, environmentFromEnv())
+	}
+}
+
+func BenchmarkReportMessage_Disabled(b *testing.B) {
+	reporter := newDisabledReporter()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = reporter.ReportMessage("message")
+	}
+}
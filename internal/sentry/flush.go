@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxConcurrentReports bounds the number of reports being captured and flushed at the same time.
+	maxConcurrentReports = 4
+
+	// reportSlotTimeout is how long a message waits for a free slot before it is dropped and counted.
+	reportSlotTimeout = time.Second
+)
+
+// flushCall is a flush which other reports can wait for.
+type flushCall struct {
+	done chan struct{}
+	ok   bool
+}
+
+// flushCoalescer lets concurrent reports share a single flush instead of each waiting for its own.
+// A report arriving while a flush is running cannot rely on it, because the flush may have started
+// before the report's event was captured; such reports share a follow-up flush instead.
+type flushCoalescer struct {
+	lock    sync.Mutex
+	running *flushCall
+	next    *flushCall
+}
+
+func (f *flushCoalescer) flush(flush func(time.Duration) bool, timeout time.Duration) bool {
+	f.lock.Lock()
+
+	if f.running == nil {
+		call := &flushCall{done: make(chan struct{})}
+		f.running = call
+		f.lock.Unlock()
+
+		return f.run(flush, timeout, call)
+	}
+
+	if call := f.next; call != nil {
+		f.lock.Unlock()
+		<-call.done
+
+		return call.ok
+	}
+
+	call, running := &flushCall{done: make(chan struct{})}, f.running
+	f.next = call
+	f.lock.Unlock()
+
+	<-running.done
+
+	return f.run(flush, timeout, call)
+}
+
+// run flushes and promotes the follow-up flush, if any, to the running one.
+func (f *flushCoalescer) run(flush func(time.Duration) bool, timeout time.Duration, call *flushCall) bool {
+	call.ok = flush(timeout)
+
+	f.lock.Lock()
+	f.running = f.next
+	f.next = nil
+	f.lock.Unlock()
+
+	close(call.done)
+
+	return call.ok
+}
+
+// acquireReport reserves a report slot. It returns false if no slot got free in time.
+// Messages wait up to reportSlotTimeout and are counted as dropped. Exceptions are never dropped,
+// so they wait up to the flush timeout and the caller reports them without a slot if none got free.
+func (r *Reporter) acquireReport(exception bool) bool {
+	select {
+	case r.reportSlots <- struct{}{}:
+		return true
+
+	default:
+	}
+
+	timeout := reportSlotTimeout
+	if exception {
+		timeout = r.getFlushTimeout()
+	}
+
+	select {
+	case r.reportSlots <- struct{}{}:
+		return true
+
+	case <-r.after(timeout):
+		if !exception {
+			r.droppedReports.Add(1)
+		}
+
+		return false
+	}
+}
+
+func (r *Reporter) releaseReport() {
+	<-r.reportSlots
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	r "github.com/stretchr/testify/require"
+)
+
+type slowTransport struct {
+	memoryTransport
+
+	delay time.Duration
+}
+
+func (t *slowTransport) Flush(time.Duration) bool {
+	time.Sleep(t.delay)
+	return true
+}
+
+func TestReporter_ConcurrentReportsAreBounded(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	transport := &slowTransport{delay: 100 * time.Millisecond}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	r.NoError(t, err)
+
+	reporter.dryRun = &dryRunSink{hub: sentry.NewHub(client, sentry.NewScope()), transport: &transport.memoryTransport}
+
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, reporter.ReportMessage("boom"))
+		}()
+	}
+
+	wg.Wait()
+
+	// Reports share flushes and messages which wait too long for a slot are dropped,
+	// so the whole batch takes far less than 100 sequential flushes.
+	r.Less(t, time.Since(start), 2*time.Second)
+
+	events := reporter.CapturedEvents()
+	r.GreaterOrEqual(t, len(events), 1)
+
+	// Drops are moved into the DroppedReports tag of the next captured report.
+	dropped := reporter.droppedReports.Load()
+	for _, event := range events {
+		if count, ok := event.Tags["DroppedReports"]; ok {
+			n, err := strconv.ParseInt(count, 10, 64)
+			r.NoError(t, err)
+			dropped += n
+		}
+	}
+
+	r.Equal(t, int64(100), int64(len(events))+dropped)
+}
+
+func TestReporter_ExceptionsAreNotDropped(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.SetFlushTimeout(10 * time.Millisecond)
+
+	// Take all slots, as if other reports were stuck flushing.
+	for i := 0; i < maxConcurrentReports; i++ {
+		r.True(t, reporter.acquireReport(false))
+	}
+
+	r.NoError(t, reporter.ReportException("boom"))
+	r.Len(t, reporter.CapturedEvents(), 1)
+	r.Zero(t, reporter.droppedReports.Load())
+
+	for i := 0; i < maxConcurrentReports; i++ {
+		reporter.releaseReport()
+	}
+}
+
+// gatedTransport blocks its first flush until the gate is closed.
+type gatedTransport struct {
+	memoryTransport
+
+	flushes atomic.Int32
+	gate    chan struct{}
+}
+
+func (t *gatedTransport) Flush(time.Duration) bool {
+	if t.flushes.Add(1) == 1 {
+		<-t.gate
+	}
+
+	return true
+}
+
+func TestFlushCoalescer_LateJoinerGetsFollowUpFlush(t *testing.T) {
+	transport := &gatedTransport{gate: make(chan struct{})}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	r.NoError(t, err)
+
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	var f flushCoalescer
+
+	first := make(chan bool)
+	go func() { first <- f.flush(hub.Flush, time.Second) }()
+
+	r.Eventually(t, func() bool { return transport.flushes.Load() == 1 }, time.Second, time.Millisecond)
+
+	// This report's event was captured after the running flush started.
+	late := make(chan bool)
+	go func() { late <- f.flush(hub.Flush, time.Second) }()
+
+	r.Eventually(t, func() bool {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		return f.next != nil
+	}, time.Second, time.Millisecond)
+
+	close(transport.gate)
+
+	r.True(t, <-first)
+	r.True(t, <-late)
+	r.Equal(t, int32(2), transport.flushes.Load())
+
+	// Once idle, a new report starts its own flush right away.
+	r.True(t, f.flush(hub.Flush, time.Second))
+	r.Equal(t, int32(3), transport.flushes.Load())
+}
+
+func TestReporter_FlushTimeout(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.SetFlushTimeout(time.Millisecond)
+
+	transport := &slowTransport{delay: 10 * time.Millisecond}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: &timeoutTransport{slowTransport: transport}})
+	r.NoError(t, err)
+
+	reporter.dryRun = &dryRunSink{hub: sentry.NewHub(client, sentry.NewScope()), transport: &transport.memoryTransport}
+
+	r.ErrorIs(t, reporter.ReportMessage("msg"), ErrFlushTimeout)
+}
+
+func TestReporter_TransportFailure(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+
+	reporter.transport = newQueueTransport(&fakeTransport{}, queue)
+	reporter.transport.tripper.failed = true
+
+	r.ErrorIs(t, reporter.ReportMessage("msg"), ErrTransportFailed)
+}
+
+// timeoutTransport reports a failed flush when the flush takes longer than the timeout.
+type timeoutTransport struct {
+	*slowTransport
+}
+
+func (t *timeoutTransport) Flush(timeout time.Duration) bool {
+	return timeout >= t.delay && t.slowTransport.Flush(timeout)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddIgnoredError makes ReportExceptionWithContext skip recovered errors matching err (via errors.Is).
+func (r *Reporter) AddIgnoredError(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.ignoredErrors = append(r.ignoredErrors, err)
+}
+
+// AddIgnoredMessage makes ReportExceptionWithContext skip recovered values whose text contains substr.
+// This covers panics with values which are not errors.
+func (r *Reporter) AddIgnoredMessage(substr string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.ignoredMessages = append(r.ignoredMessages, substr)
+}
+
+func (r *Reporter) isIgnored(i interface{}) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if err, ok := i.(error); ok {
+		for _, ignored := range r.ignoredErrors {
+			if errors.Is(err, ignored) {
+				return true
+			}
+		}
+	}
+
+	if len(r.ignoredMessages) == 0 {
+		return false
+	}
+
+	msg := fmt.Sprintf("%v", i)
+
+	for _, ignored := range r.ignoredMessages {
+		if strings.Contains(msg, ignored) {
+			return true
+		}
+	}
+
+	return false
+}
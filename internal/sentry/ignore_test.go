@@ -0,0 +1,40 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestReporter_IgnoredErrors(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.AddIgnoredError(context.Canceled)
+	reporter.AddIgnoredMessage("user logged out")
+
+	r.NoError(t, reporter.ReportException(context.Canceled))
+	r.NoError(t, reporter.ReportException(fmt.Errorf("sync: %w", context.Canceled)))
+	r.NoError(t, reporter.ReportException("panic: user logged out"))
+	r.Empty(t, reporter.CapturedEvents())
+
+	r.NoError(t, reporter.ReportException(context.DeadlineExceeded))
+	r.Len(t, reporter.CapturedEvents(), 1)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"sync"
+
+	"github.com/ProtonMail/gluon/reporter"
+)
+
+// The reporter interface shared by bridge and gluon is reporter.Reporter;
+// all reporters of this package implement it.
+var (
+	_ reporter.Reporter = (*Reporter)(nil)
+	_ reporter.Reporter = NopReporter{}
+	_ reporter.Reporter = (*RecordingReporter)(nil)
+)
+
+// NopReporter drops all reports.
+type NopReporter struct{}
+
+func (NopReporter) ReportException(interface{}) error { return nil }
+
+func (NopReporter) ReportMessage(string) error { return nil }
+
+func (NopReporter) ReportExceptionWithContext(interface{}, reporter.Context) error { return nil }
+
+func (NopReporter) ReportMessageWithContext(string, reporter.Context) error { return nil }
+
+// RecordedReport is a single report captured by RecordingReporter.
+type RecordedReport struct {
+	Exception interface{}
+	Message   string
+	Context   reporter.Context
+}
+
+// RecordingReporter keeps all reports in memory so tests can assert on them.
+type RecordingReporter struct {
+	lock    sync.Mutex
+	reports []RecordedReport
+}
+
+func (r *RecordingReporter) ReportException(i interface{}) error {
+	return r.ReportExceptionWithContext(i, nil)
+}
+
+func (r *RecordingReporter) ReportMessage(msg string) error {
+	return r.ReportMessageWithContext(msg, nil)
+}
+
+func (r *RecordingReporter) ReportExceptionWithContext(i interface{}, context reporter.Context) error {
+	r.record(RecordedReport{Exception: i, Context: context})
+	return nil
+}
+
+func (r *RecordingReporter) ReportMessageWithContext(msg string, context reporter.Context) error {
+	r.record(RecordedReport{Message: msg, Context: context})
+	return nil
+}
+
+// Reports returns a copy of all reports recorded so far.
+func (r *RecordingReporter) Reports() []RecordedReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]RecordedReport{}, r.reports...)
+}
+
+func (r *RecordingReporter) record(report RecordedReport) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.reports = append(r.reports, report)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/gluon/reporter"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestRecordingReporter(t *testing.T) {
+	rec := &RecordingReporter{}
+
+	r.NoError(t, rec.ReportException("boom"))
+	r.NoError(t, rec.ReportMessageWithContext("msg", reporter.Context{"key": "value"}))
+
+	r.Equal(t, []RecordedReport{
+		{Exception: "boom"},
+		{Message: "msg", Context: reporter.Context{"key": "value"}},
+	}, rec.Reports())
+}
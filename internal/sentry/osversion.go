@@ -0,0 +1,40 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import "github.com/elastic/go-sysinfo"
+
+const unknownOSVersion = "unknown"
+
+// getOSVersion returns the OS and kernel versions, falling back to unknownOSVersion if they cannot be detected.
+func getOSVersion() (string, string) {
+	host, err := sysinfo.Host()
+	if err != nil {
+		return unknownOSVersion, unknownOSVersion
+	}
+
+	return osVersionFromHostInfo(host.Info())
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return unknownOSVersion
+	}
+
+	return value
+}
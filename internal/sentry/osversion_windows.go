@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+// +build windows
+
+package sentry
+
+import "github.com/elastic/go-sysinfo/types"
+
+// osVersionFromHostInfo returns the Windows edition with its build number, e.g. "Windows 10 Pro (19045)".
+func osVersionFromHostInfo(info types.HostInfo) (string, string) {
+	if info.OS == nil || info.OS.Name == "" {
+		return unknownOSVersion, orUnknown(info.KernelVersion)
+	}
+
+	version := info.OS.Name
+	if info.OS.Build != "" {
+		version += " (" + info.OS.Build + ")"
+	}
+
+	return version, orUnknown(info.KernelVersion)
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+// +build windows
+
+package sentry
+
+import (
+	"testing"
+
+	"github.com/elastic/go-sysinfo/types"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestOSVersionFromHostInfo(t *testing.T) {
+	osVersion, kernelVersion := osVersionFromHostInfo(types.HostInfo{
+		KernelVersion: "10.0.19041.3208 (WinBuild.160101.0800)",
+		OS:            &types.OSInfo{Name: "Windows 10 Pro", Build: "19045.3208"},
+	})
+	r.Equal(t, "Windows 10 Pro (19045.3208)", osVersion)
+	r.Equal(t, "10.0.19041.3208 (WinBuild.160101.0800)", kernelVersion)
+
+	osVersion, kernelVersion = osVersionFromHostInfo(types.HostInfo{})
+	r.Equal(t, unknownOSVersion, osVersion)
+	r.Equal(t, unknownOSVersion, kernelVersion)
+}
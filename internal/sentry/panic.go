@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"github.com/ProtonMail/gluon/reporter"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportPanic reports a panic and panics again so the process still crashes.
+// It must be deferred directly: defer sentry.ReportPanic(reporter).
+func ReportPanic(r reporter.Reporter) {
+	SkipDuringUnwind()
+
+	if p := recover(); p != nil {
+		reportPanic(r, p, false)
+		panic(p)
+	}
+}
+
+// ReportPanicAndRecover reports a panic and swallows it so the program continues.
+// It must be deferred directly: defer sentry.ReportPanicAndRecover(reporter).
+func ReportPanicAndRecover(r reporter.Reporter) {
+	SkipDuringUnwind()
+
+	if p := recover(); p != nil {
+		reportPanic(r, p, true)
+	}
+}
+
+func reportPanic(r reporter.Reporter, p interface{}, recovered bool) {
+	SkipDuringUnwind()
+
+	context := crashContext()
+	if sentryReporter, ok := r.(*Reporter); ok {
+		context = sentryReporter.crashContext()
+	}
+
+	context["recovered"] = recovered
+
+	if err := r.ReportExceptionWithContext(p, context); err != nil {
+		logrus.WithError(err).Error("Failed to report panic")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestReportPanic_RePanics(t *testing.T) {
+	rec := &RecordingReporter{}
+
+	r.PanicsWithValue(t, "boom", func() {
+		defer ReportPanic(rec)
+		panic("boom")
+	})
+
+	reports := rec.Reports()
+	r.Len(t, reports, 1)
+	r.Equal(t, "boom", reports[0].Exception)
+	r.Equal(t, false, reports[0].Context["recovered"])
+	r.Contains(t, reports[0].Context, "build")
+	r.Contains(t, reports[0].Context, "crash")
+}
+
+func TestReportPanicAndRecover_Swallows(t *testing.T) {
+	rec := &RecordingReporter{}
+
+	r.NotPanics(t, func() {
+		defer ReportPanicAndRecover(rec)
+		panic("boom")
+	})
+
+	reports := rec.Reports()
+	r.Len(t, reports, 1)
+	r.Equal(t, "boom", reports[0].Exception)
+	r.Equal(t, true, reports[0].Context["recovered"])
+	r.Contains(t, reports[0].Context, "build")
+	r.Contains(t, reports[0].Context, "crash")
+}
+
+func TestReportPanic_CrashContext(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.SetAttachGoroutineDump(true)
+
+	r.NotPanics(t, func() {
+		defer ReportPanicAndRecover(reporter)
+		panic("boom")
+	})
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 1)
+
+	context := events[0].Contexts["bridge"]
+	r.Equal(t, "true", context["recovered"])
+	r.Contains(t, context, "build")
+	r.Contains(t, context, "crash")
+	r.Contains(t, context["goroutines"], "goroutine ")
+}
+
+func TestReportPanic_NoPanic(t *testing.T) {
+	rec := &RecordingReporter{}
+
+	func() {
+		defer ReportPanic(rec)
+	}()
+
+	r.Empty(t, rec.Reports())
+}
+
+func TestReportPanic_SkipsOwnFrames(t *testing.T) {
+	r.NotPanics(t, func() {
+		defer ReportPanicAndRecover(NopReporter{})
+		panic("boom")
+	})
+
+	r.True(t, isFunctionFilteredOut("github.com/ProtonMail/proton-bridge/v3/internal/sentry.ReportPanicAndRecover"))
+	r.True(t, isFunctionFilteredOut("github.com/ProtonMail/proton-bridge/v3/internal/sentry.reportPanic"))
+}
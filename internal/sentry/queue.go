@@ -0,0 +1,240 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// maxQueuedEvents is the number of undelivered events kept on disk. Oldest events are dropped first.
+const maxQueuedEvents = 32
+
+const queuedEventExt = ".json"
+
+// eventQueue stores events which could not be delivered so they can be sent later.
+// Events are named after the time returned by now, so they are sent in the order they were queued.
+type eventQueue struct {
+	dir  string
+	now  func() time.Time
+	lock sync.Mutex
+}
+
+func newEventQueue(dir string, now func() time.Time) (*eventQueue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create queue dir: %w", err)
+	}
+
+	return &eventQueue{dir: dir, now: now}, nil
+}
+
+// push stores the event, dropping the oldest events if the queue is full.
+func (q *eventQueue) push(event *sentry.Event) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	name := fmt.Sprintf("%020d-%s%s", q.now().UnixNano(), event.EventID, queuedEventExt)
+
+	if err := os.WriteFile(filepath.Join(q.dir, name), b, 0o600); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	names, err := q.names()
+	if err != nil {
+		return err
+	}
+
+	for len(names) > maxQueuedEvents {
+		if err := os.Remove(filepath.Join(q.dir, names[0])); err != nil {
+			return fmt.Errorf("failed to drop event: %w", err)
+		}
+
+		names = names[1:]
+	}
+
+	return nil
+}
+
+// drain sends the queued events, oldest first, and stops at the first one which could not be delivered.
+func (q *eventQueue) drain(ctx context.Context, send func(*sentry.Event) bool) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	names, err := q.names()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		path := filepath.Join(q.dir, name)
+
+		b, err := os.ReadFile(path) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("failed to read event: %w", err)
+		}
+
+		var event sentry.Event
+
+		if err := json.Unmarshal(b, &event); err != nil {
+			logrus.WithError(err).WithField("file", name).Warn("Dropping unreadable queued sentry event")
+		} else if !send(&event) {
+			return errors.New("failed to deliver queued sentry event")
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// len returns the number of queued events.
+func (q *eventQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	names, err := q.names()
+	if err != nil {
+		return 0
+	}
+
+	return len(names)
+}
+
+// names returns queued event file names sorted from oldest to newest.
+func (q *eventQueue) names() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue dir: %w", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), queuedEventExt) {
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// queueTransport sends events using the wrapped transport and queues those which did not reach the server.
+// Successful delivery triggers sending of the previously queued events.
+type queueTransport struct {
+	sentry.Transport
+
+	queue    *eventQueue
+	tripper  *outcomeRoundTripper
+	sendLock sync.Mutex
+}
+
+func newQueueTransport(transport sentry.Transport, queue *eventQueue) *queueTransport {
+	return &queueTransport{
+		Transport: transport,
+		queue:     queue,
+		tripper:   &outcomeRoundTripper{RoundTripper: http.DefaultTransport},
+	}
+}
+
+func (t *queueTransport) SendEvent(event *sentry.Event) {
+	if !t.send(event) {
+		if err := t.queue.push(event); err != nil {
+			logrus.WithError(err).Error("Failed to queue sentry event")
+		}
+
+		return
+	}
+
+	if err := t.flushQueue(context.Background()); err != nil {
+		logrus.WithError(err).Debug("Queued sentry events were not delivered")
+	}
+}
+
+func (t *queueTransport) flushQueue(ctx context.Context) error {
+	return t.queue.drain(ctx, t.send)
+}
+
+// send delivers the event and reports whether it reached the server.
+// Sending is serialized so the outcome of the round trip belongs to this event.
+func (t *queueTransport) send(event *sentry.Event) bool {
+	t.sendLock.Lock()
+	defer t.sendLock.Unlock()
+
+	t.tripper.reset()
+	t.Transport.SendEvent(event)
+
+	return !t.tripper.hasFailed()
+}
+
+// outcomeRoundTripper remembers whether the last request failed on the network or on the server.
+type outcomeRoundTripper struct {
+	http.RoundTripper
+
+	lock   sync.Mutex
+	failed bool
+}
+
+func (rt *outcomeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := rt.RoundTripper.RoundTrip(req)
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.failed = err != nil || res.StatusCode >= http.StatusInternalServerError
+
+	return res, err
+}
+
+func (rt *outcomeRoundTripper) reset() {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.failed = false
+}
+
+func (rt *outcomeRoundTripper) hasFailed() bool {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	return rt.failed
+}
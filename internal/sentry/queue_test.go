@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestEventQueue_DropsOldest(t *testing.T) {
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+
+	for i := 0; i < maxQueuedEvents+5; i++ {
+		r.NoError(t, queue.push(&sentry.Event{EventID: sentry.EventID(fmt.Sprintf("%032d", i))}))
+	}
+
+	r.Equal(t, maxQueuedEvents, queue.len())
+
+	var sent []sentry.EventID
+
+	r.NoError(t, queue.drain(context.Background(), func(event *sentry.Event) bool {
+		sent = append(sent, event.EventID)
+		return true
+	}))
+
+	r.Len(t, sent, maxQueuedEvents)
+	r.Equal(t, sentry.EventID(fmt.Sprintf("%032d", 5)), sent[0])
+	r.Zero(t, queue.len())
+}
+
+func TestEventQueue_KeepsTimestampAndTags(t *testing.T) {
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+
+	timestamp := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r.NoError(t, queue.push(&sentry.Event{
+		Message:   "msg",
+		Timestamp: timestamp,
+		Tags:      map[string]string{"Version": "1.2.3"},
+	}))
+
+	r.NoError(t, queue.drain(context.Background(), func(event *sentry.Event) bool {
+		r.Equal(t, "msg", event.Message)
+		r.True(t, timestamp.Equal(event.Timestamp))
+		r.Equal(t, map[string]string{"Version": "1.2.3"}, event.Tags)
+		return true
+	}))
+}
+
+func TestEventQueue_DrainStopsOnFailure(t *testing.T) {
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+
+	r.NoError(t, queue.push(&sentry.Event{Message: "first"}))
+	r.NoError(t, queue.push(&sentry.Event{Message: "second"}))
+
+	r.Error(t, queue.drain(context.Background(), func(*sentry.Event) bool { return false }))
+	r.Equal(t, 2, queue.len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r.ErrorIs(t, queue.drain(ctx, func(*sentry.Event) bool { return true }), context.Canceled)
+	r.Equal(t, 2, queue.len())
+}
+
+func TestQueueTransport(t *testing.T) {
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+
+	network := &fakeRoundTripper{}
+	inner := &fakeTransport{}
+	transport := newQueueTransport(inner, queue)
+	transport.tripper.RoundTripper = network
+	inner.client = &http.Client{Transport: transport.tripper}
+
+	network.offline = true
+	transport.SendEvent(&sentry.Event{Message: "offline"})
+	r.Equal(t, 1, queue.len())
+	r.Empty(t, inner.sent)
+
+	network.offline = false
+	transport.SendEvent(&sentry.Event{Message: "online"})
+	r.Zero(t, queue.len())
+	r.Equal(t, []string{"online", "offline"}, inner.sent)
+}
+
+type fakeRoundTripper struct {
+	offline bool
+}
+
+func (rt *fakeRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	if rt.offline {
+		return nil, errors.New("offline")
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+type fakeTransport struct {
+	client *http.Client
+	sent   []string
+}
+
+func (t *fakeTransport) Flush(time.Duration) bool { return true }
+
+func (t *fakeTransport) Configure(sentry.ClientOptions) {}
+
+func (t *fakeTransport) SendEvent(event *sentry.Event) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://sentry.invalid", nil)
+	if err != nil {
+		return
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return
+	}
+
+	_ = res.Body.Close()
+
+	t.sent = append(t.sent, event.Message)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
+	"github.com/getsentry/sentry-go"
+)
+
+//nolint:gochecknoglobals
+var (
+	emailPattern  = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+([a-zA-Z0-9._~+/=\-]+)`)
+	tokenPattern  = regexp.MustCompile(`\b[a-zA-Z0-9_\-]{16,}:[a-zA-Z0-9_\-]{16,}\b`)
+
+	defaultRedactors = DefaultRedactors()
+)
+
+// Redactor replaces sensitive data in a string which is about to be reported.
+type Redactor func(string) string
+
+// NewRegexpRedactor creates a redactor replacing every match of pattern with a stable hash prefix,
+// so the same value can still be correlated across reports. If the pattern contains a capture group,
+// only the first group is replaced.
+func NewRegexpRedactor(pattern *regexp.Regexp) Redactor {
+	return func(s string) string {
+		var res strings.Builder
+
+		last := 0
+
+		for _, loc := range pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) > 3 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+
+			res.WriteString(s[last:start])
+			res.WriteString(redactedValue(s[start:end]))
+
+			last = end
+		}
+
+		res.WriteString(s[last:])
+
+		return res.String()
+	}
+}
+
+// DefaultRedactors returns redactors removing email addresses, bearer tokens and uid:refresh tokens.
+func DefaultRedactors() []Redactor {
+	return []Redactor{
+		NewRegexpRedactor(emailPattern),
+		NewRegexpRedactor(bearerPattern),
+		NewRegexpRedactor(tokenPattern),
+	}
+}
+
+func redactedValue(s string) string {
+	return "[redacted:" + algo.HashHexSHA256(s)[:8] + "]"
+}
+
+func redactString(s string, redactors []Redactor) string {
+	for _, redactor := range redactors {
+		s = redactor(s)
+	}
+
+	return s
+}
+
+func redactMap(values map[string]interface{}, redactors []Redactor) {
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			values[key] = redactString(s, redactors)
+		}
+	}
+}
+
+// redactEvent applies the redactors to the message, exceptions, stack variables, breadcrumbs,
+// contexts and extra data of the event.
+func redactEvent(event *sentry.Event, redactors []Redactor) {
+	if len(redactors) == 0 {
+		return
+	}
+
+	event.Message = redactString(event.Message, redactors)
+
+	for idx := range event.Exception {
+		exception := &event.Exception[idx]
+		exception.Type = redactString(exception.Type, redactors)
+		exception.Value = redactString(exception.Value, redactors)
+
+		if exception.Stacktrace != nil {
+			for _, frame := range exception.Stacktrace.Frames {
+				redactMap(frame.Vars, redactors)
+			}
+		}
+	}
+
+	for _, breadcrumb := range event.Breadcrumbs {
+		breadcrumb.Message = redactString(breadcrumb.Message, redactors)
+		redactMap(breadcrumb.Data, redactors)
+	}
+
+	for _, context := range event.Contexts {
+		redactMap(context, redactors)
+	}
+
+	redactMap(event.Extra, redactors)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	r "github.com/stretchr/testify/require"
+)
+
+const (
+	testEmail = "user@example.com"
+	testToken = "bZ1sUI7pP0-Xw2dKx9rsT4qAZb5gIbr6:r3fr3shT0k3nwhichIsLongEnough42"
+)
+
+func TestEnhanceSentryEvent_Redacts(t *testing.T) {
+	event := &sentry.Event{
+		Message: "failed to login " + testEmail,
+		Exception: []sentry.Exception{{
+			Type:  "*errors.errorString",
+			Value: "invalid token " + testToken,
+			Stacktrace: &sentry.Stacktrace{Frames: []sentry.Frame{{
+				Function: "run",
+				Vars:     map[string]interface{}{"token": testToken, "count": 3},
+			}}},
+		}},
+		Breadcrumbs: []*sentry.Breadcrumb{{
+			Message: "Authorization: Bearer abcdef0123456789",
+			Data:    map[string]interface{}{"address": testEmail},
+		}},
+		Extra: map[string]interface{}{"token": testToken},
+	}
+
+	event.Contexts = map[string]sentry.Context{"bridge": {"address": testEmail}}
+
+	event = EnhanceSentryEvent(event, nil)
+
+	redactedEmail := redactedValue(testEmail)
+	redactedToken := redactedValue(testToken)
+
+	r.Equal(t, "failed to login "+redactedEmail, event.Message)
+	r.Equal(t, "invalid token "+redactedToken, event.Exception[0].Type)
+	r.Equal(t, redactedToken, event.Exception[0].Stacktrace.Frames[0].Vars["token"])
+	r.Equal(t, 3, event.Exception[0].Stacktrace.Frames[0].Vars["count"])
+	r.Equal(t, "Authorization: Bearer "+redactedValue("abcdef0123456789"), event.Breadcrumbs[0].Message)
+	r.Equal(t, redactedEmail, event.Breadcrumbs[0].Data["address"])
+	r.Equal(t, redactedEmail, event.Contexts["bridge"]["address"])
+	r.Equal(t, redactedToken, event.Extra["token"])
+}
+
+func TestRegexpRedactor_Stable(t *testing.T) {
+	redactor := NewRegexpRedactor(regexp.MustCompile(`secret-\d+`))
+
+	first := redactor("got secret-1 and secret-2")
+	r.Equal(t, first, redactor("got secret-1 and secret-2"))
+	r.NotContains(t, first, "secret-")
+	r.Equal(t, "nothing to hide", redactor("nothing to hide"))
+}
+
+func TestRedactEvent_CustomRedactors(t *testing.T) {
+	event := &sentry.Event{Message: "user 42 failed"}
+
+	redactEvent(event, []Redactor{NewRegexpRedactor(regexp.MustCompile(`user (\d+)`))})
+
+	r.Equal(t, "user "+redactedValue("42")+" failed", event.Message)
+}
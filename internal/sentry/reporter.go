@@ -18,12 +18,19 @@
 package sentry
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/gluon/reporter"
@@ -34,27 +41,95 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var skippedFunctions = []string{} //nolint:gochecknoglobals
+// defaultFlushTimeout is how long to wait for reports to be delivered.
+const defaultFlushTimeout = 10 * time.Second
 
-func init() { //nolint:gochecknoinits
-	sentrySyncTransport := sentry.NewHTTPSyncTransport()
-	sentrySyncTransport.Timeout = time.Second * 3
-	appVersion := constants.Version
-	version, _ := semver.NewVersion(appVersion)
-	if version != nil {
-		appVersion = version.Original()
+var (
+	// ErrFlushTimeout is returned when the report was not delivered within the flush timeout.
+	ErrFlushTimeout = errors.New("timed out flushing sentry events")
+
+	// ErrTransportFailed is returned when the report could not reach the server.
+	// If the reporter has an offline queue, the event was queued for later delivery.
+	ErrTransportFailed = errors.New("sentry transport failed")
+)
+
+// maxGoroutineDumpSize bounds the size of the goroutine dump attached to exceptions.
+const maxGoroutineDumpSize = 64 * 1024
+
+// goroutineDumpKey is the context key of the goroutine dump.
+const goroutineDumpKey = "goroutines"
+
+// maxSkippedFunctions bounds the number of functions removed from tracebacks.
+const maxSkippedFunctions = 1024
+
+const (
+	// maxContextValueSize bounds the size of a single stringified context value.
+	maxContextValueSize = 4 * 1024
+
+	// maxContextSize bounds the total size of the stringified context values.
+	maxContextSize = 16 * 1024
+)
+
+var (
+	skippedFunctions     = []string{} //nolint:gochecknoglobals
+	skippedFunctionsLock sync.RWMutex //nolint:gochecknoglobals
+)
+
+// Enable initializes the sentry client with the production options.
+// Until it is called, reports are assembled but never sent, so tests importing this package stay offline.
+func Enable() {
+	initClient(newClientOptions())
+}
+
+func mergeClientOptions(base, override sentry.ClientOptions) sentry.ClientOptions {
+	if override.Dsn != "" {
+		base.Dsn = override.Dsn
 	}
 
-	options := sentry.ClientOptions{
-		Dsn:            constants.DSNSentry,
-		Release:        constants.AppVersion(appVersion),
-		BeforeSend:     EnhanceSentryEvent,
-		Transport:      sentrySyncTransport,
-		ServerName:     GetProtectedHostname(),
-		Environment:    constants.BuildEnv,
-		MaxBreadcrumbs: 50,
+	if override.Environment != "" {
+		base.Environment = override.Environment
+	}
+
+	if override.Release != "" {
+		base.Release = override.Release
+	}
+
+	if override.ServerName != "" {
+		base.ServerName = override.ServerName
+	}
+
+	if override.SampleRate != 0 {
+		base.SampleRate = override.SampleRate
+	}
+
+	if override.MaxBreadcrumbs != 0 {
+		base.MaxBreadcrumbs = override.MaxBreadcrumbs
+	}
+
+	if override.BeforeSend != nil {
+		base.BeforeSend = override.BeforeSend
 	}
 
+	if override.Transport != nil {
+		base.Transport = override.Transport
+	}
+
+	if override.HTTPTransport != nil {
+		base.HTTPTransport = override.HTTPTransport
+	}
+
+	if override.HTTPProxy != "" {
+		base.HTTPProxy = override.HTTPProxy
+	}
+
+	if override.HTTPSProxy != "" {
+		base.HTTPSProxy = override.HTTPSProxy
+	}
+
+	return base
+}
+
+func initClient(options sentry.ClientOptions) {
 	if err := sentry.Init(options); err != nil {
 		logrus.WithError(err).Error("Failed to initialize sentry options")
 	}
@@ -70,11 +145,58 @@ func init() { //nolint:gochecknoinits
 	)
 }
 
+func newClientOptions() sentry.ClientOptions {
+	sentrySyncTransport := sentry.NewHTTPSyncTransport()
+	sentrySyncTransport.Timeout = time.Second * 3
+	appVersion := constants.Version
+	version, _ := semver.NewVersion(appVersion)
+	if version != nil {
+		appVersion = version.Original()
+	}
+
+	return sentry.ClientOptions{
+		Dsn:            constants.DSNSentry,
+		Release:        constants.AppVersion(appVersion),
+		BeforeSend:     EnhanceSentryEvent,
+		Transport:      sentrySyncTransport,
+		ServerName:     GetProtectedHostname(),
+		Environment:    constants.BuildEnv,
+		MaxBreadcrumbs: 50,
+	}
+}
+
 type Reporter struct {
 	appName    string
 	appVersion string
 	identifier Identifier
 	hostArch   string
+	cpuArch    string
+	osVersion  string
+	kernel     string
+	deduper    *messageDeduper
+
+	reportSlots       chan struct{}
+	droppedReports    atomic.Int64
+	sampler           *sampler
+	sampledOutReports atomic.Int64
+	flusher           flushCoalescer
+	closeOnce         sync.Once
+
+	// lock guards the settings below, which can change while reports are being sent.
+	lock                 sync.RWMutex
+	transport            *queueTransport
+	redactors            []Redactor
+	ignoredErrors        []error
+	ignoredMessages      []string
+	dryRun               *dryRunSink
+	clock                Clock
+	attachGoroutineDump  bool
+	environment          string
+	reportDevEnvironment bool
+	flushTimeout         time.Duration
+	userID               string
+	messageSampleRate    float64
+	exceptionSampleRate  float64
 }
 
 type Identifier interface {
@@ -96,34 +218,270 @@ func GetTimeZone() string {
 
 // NewReporter creates new sentry reporter with appName and appVersion to report.
 func NewReporter(appName string, identifier Identifier) *Reporter {
+	osVersion, kernel := getOSVersion()
+	clock := realClock{}
+
 	return &Reporter{
-		appName:    appName,
-		appVersion: constants.Revision,
-		identifier: identifier,
-		hostArch:   getHostArch(),
+		appName:      appName,
+		appVersion:   constants.Revision,
+		identifier:   identifier,
+		hostArch:     getHostArch(),
+		cpuArch:      getCPUArch(),
+		osVersion:    osVersion,
+		kernel:       kernel,
+		deduper:      newMessageDeduper(defaultDedupWindow),
+		reportSlots:  make(chan struct{}, maxConcurrentReports),
+		clock:        clock,
+		environment:  environmentFromEnv(),
+		flushTimeout: defaultFlushTimeout,
+		sampler:      newSampler(clock.Now().UnixNano()),
+
+		messageSampleRate:   1,
+		exceptionSampleRate: 1,
 	}
 }
 
+// NewReporterWithQueue creates new sentry reporter which stores events that could not be delivered
+// in queueDir and retries them on the next successful report or on FlushQueue.
+// It enables the sentry client, there is no need to call Enable.
+func NewReporterWithQueue(appName, appVersion string, identifier Identifier, queueDir string) *Reporter {
+	reporter := NewReporter(appName, identifier)
+	reporter.appVersion = appVersion
+
+	queue, err := newEventQueue(queueDir, reporter.now)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create sentry event queue")
+		return reporter
+	}
+
+	options := newClientOptions()
+	reporter.transport = newQueueTransport(options.Transport, queue)
+	options.Transport = reporter.transport
+	options.HTTPTransport = reporter.transport.tripper
+
+	initClient(options)
+
+	return reporter
+}
+
+// AddRedactors registers redactors applied to every report on top of the default ones.
+func (r *Reporter) AddRedactors(redactors ...Redactor) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.redactors = append(r.redactors, redactors...)
+}
+
+// SetDedupWindow sets the interval in which identical messages are reported only once.
+// The number of suppressed duplicates is sent with the next allowed report. Zero disables deduplication.
+func (r *Reporter) SetDedupWindow(d time.Duration) {
+	r.deduper.setWindow(d)
+}
+
+// SetAttachGoroutineDump makes ReportException attach the stacks of all goroutines,
+// which helps with deadlock and hang crashes.
+func (r *Reporter) SetAttachGoroutineDump(attach bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.attachGoroutineDump = attach
+}
+
+// FlushQueue tries to deliver all events stored in the queue, oldest first.
+func (r *Reporter) FlushQueue(ctx context.Context) error {
+	transport := r.getTransport()
+	if transport == nil {
+		return nil
+	}
+
+	return transport.flushQueue(ctx)
+}
+
+// ReInit re-initializes the sentry client, e.g. to send reports to a self-hosted sentry.
+// Only the non-zero fields of options are applied on top of the production options,
+// so BeforeSend and the sync transport stay in place unless overridden.
+// The reporter's event queue and message batching, if enabled, are kept around the new transport.
+func (r *Reporter) ReInit(options sentry.ClientOptions) {
+	merged := mergeClientOptions(newClientOptions(), options)
+
+	r.lock.Lock()
+	if r.transport != nil {
+		r.transport = newQueueTransport(merged.Transport, r.transport.queue)
+
+		if merged.HTTPTransport != nil {
+			r.transport.tripper.RoundTripper = merged.HTTPTransport
+		}
+
+		merged.Transport = r.transport
+		merged.HTTPTransport = r.transport.tripper
+	}
+	r.lock.Unlock()
+
+	if batch := currentBatchTransport(); batch != nil {
+		// The buffered events were reported with the previous options, send them there.
+		batch.send()
+		batch.stop()
+
+		merged.Transport = newSplitTransport(merged.Transport, newBatchTransport(merged.Transport, batch.options))
+	}
+
+	initClient(merged)
+}
+
+func (r *Reporter) getTransport() *queueTransport {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.transport
+}
+
+// Close sends the queued and buffered reports, giving up when ctx is done.
+// It is safe to call it more than once; only the first call flushes.
+func (r *Reporter) Close(ctx context.Context) error {
+	var err error
+
+	r.closeOnce.Do(func() {
+		err = r.close(ctx)
+	})
+
+	return err
+}
+
+// close flushes both the queue and the hub, so an unreachable queue does not drop the buffered reports.
+// The batch transport, if any, is stopped afterwards so its goroutine does not outlive the reporter.
+func (r *Reporter) close(ctx context.Context) error {
+	var errs []error
+
+	if err := r.reportSampledOut(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to report sampled out reports: %w", err))
+	}
+
+	if err := r.FlushQueue(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush sentry queue: %w", err))
+	}
+
+	timeout := r.getFlushTimeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	hub := r.hub()
+
+	if !hub.Flush(timeout) {
+		errs = append(errs, ErrFlushTimeout)
+	}
+
+	if batch := hubBatchTransport(hub); batch != nil {
+		batch.stop()
+	}
+
+	return errors.Join(errs...)
+}
+
+// SetFlushTimeout sets how long a report waits for the event to be delivered.
+func (r *Reporter) SetFlushTimeout(d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.flushTimeout = d
+}
+
+func (r *Reporter) getFlushTimeout() time.Duration {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.flushTimeout
+}
+
+// UserIDContextKey is the report context key of the user the report is about. The ID is hashed into
+// the UserID tag and removed from the context, and takes precedence over the ID set with SetUserID.
+const UserIDContextKey = "userID"
+
+// SetUserID tags subsequent reports with a hash of the given user ID, so crashes can be correlated
+// to an account without sending the ID itself. An empty ID removes the tag.
+// The ID is shared by all reports of the process, so it should only be set for the primary user;
+// reports about a given account pass its ID in the context under UserIDContextKey instead.
+func (r *Reporter) SetUserID(userID string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if userID == "" {
+		r.userID = ""
+		return
+	}
+
+	r.userID = algo.HashHexSHA256(userID)
+}
+
+// hub returns the hub the reports are captured with.
+func (r *Reporter) hub() *sentry.Hub {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.dryRun != nil {
+		return r.dryRun.hub
+	}
+
+	return sentry.CurrentHub()
+}
+
 func (r *Reporter) ReportException(i interface{}) error {
+	if r.isDisabled() {
+		return nil
+	}
+
 	SkipDuringUnwind()
-	return r.ReportExceptionWithContext(i, map[string]interface{}{
+
+	return r.ReportExceptionWithContext(i, r.crashContext())
+}
+
+// crashContext returns the context attached to crashes, including the goroutine dump if enabled.
+func (r *Reporter) crashContext() map[string]interface{} {
+	context := crashContext()
+
+	r.lock.RLock()
+	attachGoroutineDump := r.attachGoroutineDump
+	r.lock.RUnlock()
+
+	if attachGoroutineDump {
+		context[goroutineDumpKey] = goroutineDump()
+	}
+
+	return context
+}
+
+// crashContext returns the build and crash count context attached to crashes.
+func crashContext() map[string]interface{} {
+	return map[string]interface{}{
 		"build": constants.BuildTime,
 		"crash": os.Getenv(restarter.BridgeCrashCount),
-	})
+	}
 }
 
 func (r *Reporter) ReportMessage(msg string) error {
+	if r.isDisabled() {
+		return nil
+	}
+
 	SkipDuringUnwind()
 	return r.ReportMessageWithContext(msg, make(map[string]interface{}))
 }
 
 func (r *Reporter) ReportExceptionWithContext(i interface{}, context map[string]interface{}) error {
+	if r.isDisabled() {
+		return nil
+	}
+
 	SkipDuringUnwind()
 
+	if r.isIgnored(i) || !r.sampleException() {
+		return nil
+	}
+
 	err := fmt.Errorf("recover: %v", i)
-	return r.scopedReport(context, func() {
+	return r.scopedReport(context, true, sentry.LevelError, func(hub *sentry.Hub, _ *sentry.Scope) {
 		SkipDuringUnwind()
-		if eventID := sentry.CaptureException(err); eventID != nil {
+		if eventID := hub.CaptureException(err); eventID != nil {
 			logrus.WithError(err).
 				WithField("reportID", *eventID).
 				Warn("Captured exception")
@@ -132,11 +490,65 @@ func (r *Reporter) ReportExceptionWithContext(i interface{}, context map[string]
 }
 
 func (r *Reporter) ReportMessageWithContext(msg string, context map[string]interface{}) error {
+	if r.isDisabled() {
+		return nil
+	}
+
+	SkipDuringUnwind()
+	return r.ReportMessageWithLevel(msg, sentry.LevelInfo, context)
+}
+
+// ReportMessageWithLevel reports the message with the given severity level.
+func (r *Reporter) ReportMessageWithLevel(msg string, level sentry.Level, context map[string]interface{}) error {
+	if r.isDisabled() {
+		return nil
+	}
+
 	SkipDuringUnwind()
-	return r.scopedReport(context, func() {
+	return r.reportMessage(msg, level, nil, context)
+}
+
+// ReportWarning reports a non-fatal warning fingerprinted by key, so warnings with the same key
+// are grouped together and operationally distinct warnings stay apart in the dashboard.
+func (r *Reporter) ReportWarning(key, msg string, context map[string]interface{}) error {
+	if r.isDisabled() {
+		return nil
+	}
+
+	SkipDuringUnwind()
+	return r.reportMessage(msg, sentry.LevelWarning, []string{"warning", key}, context)
+}
+
+func (r *Reporter) reportMessage(msg string, level sentry.Level, fingerprint []string, context map[string]interface{}) error {
+	SkipDuringUnwind()
+
+	if !r.sampleMessage() {
+		return nil
+	}
+
+	key := msg
+	if len(fingerprint) != 0 {
+		key = strings.Join(fingerprint, "/") + ": " + msg
+	}
+
+	send, suppressed := r.deduper.allow(messageKey(key, context), r.now())
+	if !send {
+		return nil
+	}
+
+	if suppressed > 0 {
+		context = withSuppressedCount(context, suppressed)
+	}
+
+	return r.scopedReport(context, false, level, func(hub *sentry.Hub, scope *sentry.Scope) {
 		SkipDuringUnwind()
-		if eventID := sentry.CaptureMessage(msg); eventID != nil {
+		scope.SetLevel(level)
+		if len(fingerprint) != 0 {
+			scope.SetFingerprint(fingerprint)
+		}
+		if eventID := hub.CaptureMessage(msg); eventID != nil {
 			logrus.WithField("message", msg).
+				WithField("level", level).
 				WithField("reportID", *eventID).
 				Warn("Captured message")
 		}
@@ -144,34 +556,101 @@ func (r *Reporter) ReportMessageWithContext(msg string, context map[string]inter
 }
 
 // Report reports a sentry crash with stacktrace from all goroutines.
-func (r *Reporter) scopedReport(context map[string]interface{}, doReport func()) error {
+// Exception and level describe the reported event; events sent in the background are not waited for.
+func (r *Reporter) scopedReport(
+	context map[string]interface{},
+	exception bool,
+	level sentry.Level,
+	doReport func(*sentry.Hub, *sentry.Scope),
+) error {
 	SkipDuringUnwind()
 
-	if os.Getenv("PROTONMAIL_ENV") == "dev" {
+	r.lock.RLock()
+	redactors := r.redactors
+	dryRun := r.dryRun
+	environment := r.environment
+	reportDevEnvironment := r.reportDevEnvironment
+	userID := r.userID
+	r.lock.RUnlock()
+
+	hub := sentry.CurrentHub()
+
+	if dryRun != nil {
+		hub = dryRun.hub
+	} else if environment == EnvironmentDev && !reportDevEnvironment {
+		return nil
+	}
+
+	if r.acquireReport(exception) {
+		defer r.releaseReport()
+	} else if !exception {
+		logrus.Warn("Too many concurrent sentry reports, dropping report")
 		return nil
 	}
 
 	tags := map[string]string{
-		"OS":        runtime.GOOS,
-		"Client":    r.appName,
-		"Version":   r.appVersion,
-		"UserAgent": r.identifier.GetUserAgent(),
-		"HostArch":  r.hostArch,
+		"OS":         runtime.GOOS,
+		"Client":     r.appName,
+		"Version":    r.appVersion,
+		"UserAgent":  r.identifier.GetUserAgent(),
+		"HostArch":   r.hostArch,
+		"BinaryArch": runtime.GOARCH,
+		"CPUArch":    r.cpuArch,
+		"OSVersion":  r.osVersion,
+		"Kernel":     r.kernel,
 	}
 
-	sentry.WithScope(func(scope *sentry.Scope) {
+	addBuildTags(tags)
+
+	if userID != "" {
+		tags["UserID"] = userID
+	}
+
+	if id, ok := context[UserIDContextKey]; ok {
+		if id := fmt.Sprintf("%v", id); id != "" {
+			tags["UserID"] = algo.HashHexSHA256(id)
+		}
+
+		context = withoutKey(context, UserIDContextKey)
+	}
+
+	if dropped := r.droppedReports.Swap(0); dropped > 0 {
+		tags["DroppedReports"] = strconv.FormatInt(dropped, 10)
+	}
+
+	if sampledOut := r.sampledOutReports.Swap(0); sampledOut > 0 {
+		tags["SampledOutReports"] = strconv.FormatInt(sampledOut, 10)
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
 		SkipDuringUnwind()
 		scope.SetTags(tags)
+		scope.SetTag("environment", environment)
+		if len(redactors) != 0 {
+			scope.AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+				redactEvent(event, redactors)
+				return event
+			})
+		}
 		if len(context) != 0 {
 			scope.SetContexts(
-				map[string]sentry.Context{"bridge": contextToString(context)},
+				map[string]sentry.Context{"bridge": contextToString(context, redactors)},
 			)
 		}
-		doReport()
+		doReport(hub, scope)
 	})
 
-	if !sentry.Flush(time.Second * 10) {
-		return errors.New("failed to report sentry error")
+	flush := reportFlusher(hub, exception, level)
+	if flush == nil {
+		return nil
+	}
+
+	if !r.flusher.flush(flush, r.getFlushTimeout()) {
+		return fmt.Errorf("failed to report sentry error: %w", ErrFlushTimeout)
+	}
+
+	if transport := r.getTransport(); transport != nil && transport.tripper.hasFailed() {
+		return fmt.Errorf("failed to report sentry error: %w", ErrTransportFailed)
 	}
 
 	return nil
@@ -199,11 +678,50 @@ func SkipDuringUnwind() {
 		return
 	}
 
-	skippedFunctions = append(skippedFunctions, frame.Function)
+	skippedFunctionsLock.Lock()
+	defer skippedFunctionsLock.Unlock()
+
+	// Another goroutine may have added the same function in the meantime.
+	addSkippedFunctionLocked(frame.Function)
+}
+
+// AddSkippedFunction registers a function, by its fully qualified name, to be removed from every traceback.
+// Unlike SkipDuringUnwind it does not need to run on the crashing goroutine first.
+func AddSkippedFunction(function string) {
+	skippedFunctionsLock.Lock()
+	defer skippedFunctionsLock.Unlock()
+
+	addSkippedFunctionLocked(function)
+}
+
+// SetSkippedFunctions replaces the functions removed from tracebacks.
+func SetSkippedFunctions(functions []string) {
+	skippedFunctionsLock.Lock()
+	defer skippedFunctionsLock.Unlock()
+
+	skippedFunctions = []string{}
+
+	for _, function := range functions {
+		addSkippedFunctionLocked(function)
+	}
 }
 
-// EnhanceSentryEvent swaps type with value and removes panic handlers from the stacktrace.
+// addSkippedFunctionLocked adds the function unless it is already skipped or maxSkippedFunctions is reached.
+func addSkippedFunctionLocked(function string) {
+	if isFunctionFilteredOutLocked(function) || len(skippedFunctions) >= maxSkippedFunctions {
+		return
+	}
+
+	skippedFunctions = append(skippedFunctions, function)
+}
+
+// EnhanceSentryEvent swaps type with value, removes panic handlers from the stacktrace
+// and redacts email addresses and tokens.
 func EnhanceSentryEvent(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if event == nil {
+		return nil
+	}
+
 	for idx, exception := range event.Exception {
 		exception.Type, exception.Value = exception.Value, exception.Type
 		if exception.Stacktrace != nil {
@@ -211,6 +729,7 @@ func EnhanceSentryEvent(event *sentry.Event, _ *sentry.EventHint) *sentry.Event
 		}
 		event.Exception[idx] = exception
 	}
+	redactEvent(event, defaultRedactors)
 	return event
 }
 
@@ -227,6 +746,13 @@ func filterOutPanicHandlers(frames []sentry.Frame) []sentry.Frame {
 }
 
 func isFunctionFilteredOut(function string) bool {
+	skippedFunctionsLock.RLock()
+	defer skippedFunctionsLock.RUnlock()
+
+	return isFunctionFilteredOutLocked(function)
+}
+
+func isFunctionFilteredOutLocked(function string) bool {
 	for _, skipFunction := range skippedFunctions {
 		if function == skipFunction {
 			return true
@@ -239,12 +765,123 @@ func Flush(maxWaiTime time.Duration) {
 	sentry.Flush(maxWaiTime)
 }
 
-func contextToString(context sentry.Context) sentry.Context {
+// goroutineDump returns the stacks of all goroutines, truncated to maxGoroutineDumpSize.
+func goroutineDump() string {
+	buf := make([]byte, maxGoroutineDumpSize)
+	n := runtime.Stack(buf, true)
+
+	if n == len(buf) {
+		return string(buf[:n]) + "\n... truncated"
+	}
+
+	return string(buf[:n])
+}
+
+// addBuildTags adds the build metadata as tags so the reports can be filtered by build.
+// Values which are not set are left out.
+func addBuildTags(tags map[string]string) {
+	if constants.BuildTime != "" {
+		tags["BuildTime"] = constants.BuildTime
+	}
+
+	if constants.Revision != "" {
+		tags["Revision"] = constants.Revision
+	}
+
+	if crashCount := os.Getenv(restarter.BridgeCrashCount); crashCount != "" {
+		tags["CrashCount"] = crashCount
+	}
+}
+
+func withSuppressedCount(context map[string]interface{}, suppressed int) map[string]interface{} {
+	res := make(map[string]interface{}, len(context)+1)
+
+	for k, v := range context {
+		res[k] = v
+	}
+
+	res["suppressed"] = suppressed
+
+	return res
+}
+
+func withoutKey(context map[string]interface{}, key string) map[string]interface{} {
+	res := make(map[string]interface{}, len(context))
+
+	for k, v := range context {
+		if k != key {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+// contextToString stringifies the context values. Values are capped at maxContextValueSize and the whole
+// context at maxContextSize, so a big object cannot make sentry reject the report.
+// Truncated values end with an ellipsis and the context gets a "_truncated" marker.
+// The goroutine dump is exempt; it is already bounded by maxGoroutineDumpSize.
+// Values are redacted before they are cut, as a secret cut in half would no longer match its redactor.
+func contextToString(context sentry.Context, redactors []Redactor) sentry.Context {
 	res := make(sentry.Context)
 
+	keys := make([]string, 0, len(context))
 	for k, v := range context {
-		res[k] = fmt.Sprintf("%v", v)
+		if k == goroutineDumpKey {
+			res[k] = redactContextValue(v, redactors)
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	budget := maxContextSize
+	truncated := false
+
+	for _, k := range keys {
+		limit := maxContextValueSize
+		if budget < limit {
+			limit = budget
+		}
+
+		value, cut := truncateString(redactContextValue(context[k], redactors), limit)
+		if cut {
+			value += "..."
+			truncated = true
+		}
+
+		budget -= len(value)
+		if budget < 0 {
+			budget = 0
+		}
+		res[k] = value
+	}
+
+	if truncated {
+		res["_truncated"] = true
 	}
 
 	return res
 }
+
+// redactContextValue stringifies v and applies the default redactors and the given ones.
+func redactContextValue(v interface{}, redactors []Redactor) string {
+	return redactString(redactString(fmt.Sprintf("%v", v), defaultRedactors), redactors)
+}
+
+// truncateString cuts s to at most size bytes without splitting a multi-byte character.
+func truncateString(s string, size int) (string, bool) {
+	if len(s) <= size {
+		return s, false
+	}
+
+	s = s[:size]
+
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s, true
+}
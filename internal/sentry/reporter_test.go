@@ -18,14 +18,26 @@
 package sentry
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
+	"github.com/stretchr/testify/assert"
 	r "github.com/stretchr/testify/require"
 
 	"github.com/getsentry/sentry-go"
 )
 
 func TestSkipDuringUnwind(t *testing.T) {
+	// Other tests report through the reporter which registers its own functions.
+	skippedFunctions = []string{}
+
 	// More calls in one function adds it only once.
 	SkipDuringUnwind()
 	SkipDuringUnwind()
@@ -64,3 +76,347 @@ func TestFilterOutPanicHandlers(t *testing.T) {
 	gotFrames := filterOutPanicHandlers(frames)
 	r.Equal(t, frames[:5], gotFrames)
 }
+
+type fakeIdentifier struct{}
+
+func (fakeIdentifier) GetUserAgent() string {
+	return "fake-agent"
+}
+
+func TestConcurrentSkipDuringUnwind(t *testing.T) {
+	// Dry run, so the reports really go through the scoped report path.
+	reporter := newDryRunReporter(t)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, reporter.ReportMessage("concurrent"))
+		}()
+
+		go func() {
+			defer wg.Done()
+			SkipDuringUnwind()
+			_ = filterOutPanicHandlers([]sentry.Frame{{Module: "main", Function: "run"}})
+		}()
+	}
+
+	wg.Wait()
+
+	r.Len(t, reporter.CapturedEvents(), 50)
+
+	// Each function must be recorded at most once.
+	seen := make(map[string]struct{})
+	for _, function := range skippedFunctions {
+		_, ok := seen[function]
+		r.False(t, ok, function)
+		seen[function] = struct{}{}
+	}
+}
+
+func TestSetSkippedFunctions(t *testing.T) {
+	SetSkippedFunctions([]string{
+		"github.com/ProtonMail/proton-bridge/v3/pkg/config.HandlePanic",
+		"github.com/ProtonMail/proton-bridge/v3/pkg/config.HandlePanic",
+	})
+	AddSkippedFunction("github.com/ProtonMail/proton-bridge/v3/internal/crash.(*Handler).HandlePanic")
+	AddSkippedFunction("github.com/ProtonMail/proton-bridge/v3/internal/crash.(*Handler).HandlePanic")
+
+	r.Equal(t, []string{
+		"github.com/ProtonMail/proton-bridge/v3/pkg/config.HandlePanic",
+		"github.com/ProtonMail/proton-bridge/v3/internal/crash.(*Handler).HandlePanic",
+	}, skippedFunctions)
+
+	frames := []sentry.Frame{
+		{Module: "main", Function: "run"},
+		{Module: "github.com/ProtonMail/proton-bridge/v3/pkg/config", Function: "HandlePanic"},
+		{Module: "github.com/ProtonMail/proton-bridge/v3/internal/crash", Function: "(*Handler).HandlePanic"},
+	}
+
+	r.Equal(t, frames[:1], filterOutPanicHandlers(frames))
+}
+
+func TestSkippedFunctionsLimit(t *testing.T) {
+	functions := make([]string, 0, maxSkippedFunctions+1)
+	for i := 0; i <= maxSkippedFunctions; i++ {
+		functions = append(functions, fmt.Sprintf("pkg.function%d", i))
+	}
+
+	SetSkippedFunctions(functions)
+	r.Len(t, skippedFunctions, maxSkippedFunctions)
+
+	AddSkippedFunction("pkg.extra")
+	r.Len(t, skippedFunctions, maxSkippedFunctions)
+	r.False(t, isFunctionFilteredOut("pkg.extra"))
+
+	SetSkippedFunctions(nil)
+}
+
+func TestMergeClientOptions(t *testing.T) {
+	base := newClientOptions()
+
+	merged := mergeClientOptions(base, sentry.ClientOptions{
+		Dsn:         "https://key@sentry.example.com/1",
+		Environment: "enterprise",
+		SampleRate:  0.5,
+	})
+
+	r.Equal(t, "https://key@sentry.example.com/1", merged.Dsn)
+	r.Equal(t, "enterprise", merged.Environment)
+	r.Equal(t, 0.5, merged.SampleRate)
+
+	// Defaults are kept unless overridden.
+	r.Equal(t, base.Release, merged.Release)
+	r.Equal(t, base.Transport, merged.Transport)
+	r.NotNil(t, merged.BeforeSend)
+	r.Equal(t, base.MaxBreadcrumbs, merged.MaxBreadcrumbs)
+}
+
+func TestContextToString(t *testing.T) {
+	res := contextToString(sentry.Context{"count": 3, "name": "bridge"}, nil)
+
+	r.Equal(t, sentry.Context{"count": "3", "name": "bridge"}, res)
+}
+
+func TestContextToString_OversizedValue(t *testing.T) {
+	res := contextToString(sentry.Context{
+		"big":   strings.Repeat("x", 2*maxContextValueSize),
+		"small": "ok",
+	}, nil)
+
+	big, ok := res["big"].(string)
+	r.True(t, ok)
+	r.Equal(t, strings.Repeat("x", maxContextValueSize)+"...", big)
+	r.Equal(t, "ok", res["small"])
+	r.Equal(t, true, res["_truncated"])
+}
+
+func TestContextToString_GoroutineDumpNotCapped(t *testing.T) {
+	dump := strings.Repeat("x", maxGoroutineDumpSize)
+
+	res := contextToString(sentry.Context{goroutineDumpKey: dump, "build": "now"}, nil)
+
+	r.Equal(t, dump, res[goroutineDumpKey])
+	r.Equal(t, "now", res["build"])
+	r.NotContains(t, res, "_truncated")
+}
+
+func TestContextToString_RedactsBeforeTruncating(t *testing.T) {
+	token := "abcdefghijklmnopqrstuvwx:abcdefghijklmnopqrstuvwx"
+	email := "someone@example.com"
+
+	// Both secrets straddle the value size cap.
+	res := contextToString(sentry.Context{
+		"token": strings.Repeat("x", maxContextValueSize-10) + " " + token,
+		"email": strings.Repeat("x", maxContextValueSize-5) + " " + email,
+	}, nil)
+
+	for _, key := range []string{"token", "email"} {
+		value, ok := res[key].(string)
+		r.True(t, ok)
+		r.LessOrEqual(t, len(value), maxContextValueSize+len("..."))
+		r.NotContains(t, value, "abcdefghi")
+		r.NotContains(t, value, "someone")
+	}
+
+	r.Equal(t, true, res["_truncated"])
+}
+
+func TestContextToString_TotalSize(t *testing.T) {
+	context := sentry.Context{}
+	for i := 0; i < 10; i++ {
+		context[string(rune('a'+i))] = strings.Repeat("x", maxContextValueSize)
+	}
+
+	res := contextToString(context, nil)
+
+	total := 0
+	for k, v := range res {
+		if k != "_truncated" {
+			total += len(v.(string)) //nolint:forcetypeassert
+		}
+	}
+
+	r.LessOrEqual(t, total, maxContextSize+len(context)*len("..."))
+	r.Equal(t, strings.Repeat("x", maxContextValueSize), res["a"])
+	r.Equal(t, "...", res["j"])
+	r.Equal(t, true, res["_truncated"])
+}
+
+func TestEnhanceSentryEvent(t *testing.T) {
+	skippedFunctions = []string{"github.com/ProtonMail/proton-bridge/v3/internal/sentry.ReportSentryCrash"}
+
+	event := &sentry.Event{
+		Exception: []sentry.Exception{{
+			Type:  "*errors.errorString",
+			Value: "recover: boom",
+			Stacktrace: &sentry.Stacktrace{Frames: []sentry.Frame{
+				{Module: "main", Function: "main"},
+				{Module: "github.com/ProtonMail/proton-bridge/v3/internal/sentry", Function: "ReportSentryCrash"},
+			}},
+		}, {
+			Type:  "*fmt.wrapError",
+			Value: "no stacktrace",
+		}},
+	}
+
+	got := EnhanceSentryEvent(event, nil)
+	r.Same(t, event, got)
+
+	// Type and value are swapped so sentry groups by the panic message.
+	r.Equal(t, "recover: boom", got.Exception[0].Type)
+	r.Equal(t, "*errors.errorString", got.Exception[0].Value)
+	r.Equal(t, []sentry.Frame{{Module: "main", Function: "main"}}, got.Exception[0].Stacktrace.Frames)
+
+	r.Equal(t, "no stacktrace", got.Exception[1].Type)
+	r.Equal(t, "*fmt.wrapError", got.Exception[1].Value)
+	r.Nil(t, got.Exception[1].Stacktrace)
+}
+
+func TestEnhanceSentryEvent_Empty(t *testing.T) {
+	r.Nil(t, EnhanceSentryEvent(nil, nil))
+
+	event := &sentry.Event{Message: "message"}
+	r.Equal(t, &sentry.Event{Message: "message"}, EnhanceSentryEvent(event, nil))
+}
+
+func TestReporter_Close(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r.NoError(t, reporter.Close(ctx))
+	r.NoError(t, reporter.Close(ctx))
+}
+
+func TestReporter_CloseFlushesQueue(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+	r.NoError(t, queue.push(&sentry.Event{Message: "queued"}))
+
+	inner := &fakeTransport{}
+	reporter.transport = newQueueTransport(inner, queue)
+	reporter.transport.tripper.RoundTripper = &fakeRoundTripper{}
+	inner.client = &http.Client{Transport: reporter.transport.tripper}
+
+	r.NoError(t, reporter.Close(context.Background()))
+	r.Equal(t, []string{"queued"}, inner.sent)
+	r.Zero(t, queue.len())
+}
+
+// flushTrackingTransport records whether it was flushed.
+type flushTrackingTransport struct {
+	memoryTransport
+	flushed atomic.Bool
+}
+
+func (t *flushTrackingTransport) Flush(time.Duration) bool {
+	t.flushed.Store(true)
+	return true
+}
+
+func TestReporter_CloseFlushesHubWhenQueueFails(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	transport := &flushTrackingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	r.NoError(t, err)
+	reporter.dryRun = &dryRunSink{hub: sentry.NewHub(client, sentry.NewScope()), transport: &transport.memoryTransport}
+
+	queue, err := newEventQueue(t.TempDir(), time.Now)
+	r.NoError(t, err)
+	r.NoError(t, queue.push(&sentry.Event{Message: "queued"}))
+
+	inner := &fakeTransport{}
+	reporter.transport = newQueueTransport(inner, queue)
+	reporter.transport.tripper.RoundTripper = &fakeRoundTripper{offline: true}
+	inner.client = &http.Client{Transport: reporter.transport.tripper}
+
+	err = reporter.Close(context.Background())
+	r.Error(t, err)
+	r.NotErrorIs(t, err, ErrFlushTimeout)
+	r.True(t, transport.flushed.Load())
+	r.Equal(t, 1, queue.len())
+}
+
+func TestReporter_ReInitKeepsQueue(t *testing.T) {
+	defer initClient(sentry.ClientOptions{})
+
+	reporter := NewReporterWithQueue("test", "1.0.0", fakeIdentifier{}, t.TempDir())
+	previous := reporter.getTransport()
+	r.NotNil(t, previous)
+
+	// The last send before re-initializing failed.
+	previous.tripper.failed = true
+
+	reporter.ReInit(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1"})
+
+	transport := reporter.getTransport()
+	r.NotSame(t, previous, transport)
+	r.Same(t, previous.queue, transport.queue)
+	r.False(t, transport.tripper.hasFailed())
+
+	client := sentry.CurrentHub().Client()
+	r.Equal(t, "https://key@sentry.example.com/1", client.Options().Dsn)
+	r.Equal(t, transport, client.Transport)
+	r.Equal(t, transport.tripper, client.Options().HTTPTransport)
+}
+
+func TestReporter_ReInitKeepsBatching(t *testing.T) {
+	defer initClient(sentry.ClientOptions{})
+
+	EnableWithBatching(BatchOptions{BufferSize: 5, FlushInterval: time.Hour})
+	previous := currentBatchTransport()
+	r.NotNil(t, previous)
+
+	reporter := NewReporter("test", fakeIdentifier{})
+	reporter.ReInit(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1"})
+
+	batch := currentBatchTransport()
+	r.NotNil(t, batch)
+	r.NotSame(t, previous, batch)
+	r.Equal(t, previous.options, batch.options)
+
+	batch.stop()
+}
+
+func TestReporter_SetUserID(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	r.NoError(t, reporter.ReportMessage("first"))
+	r.NotContains(t, reporter.CapturedEvents()[0].Tags, "UserID")
+
+	reporter.SetUserID("user-id")
+
+	r.NoError(t, reporter.ReportMessage("second"))
+
+	userID := reporter.CapturedEvents()[1].Tags["UserID"]
+	r.NotEmpty(t, userID)
+	r.NotContains(t, userID, "user-id")
+}
+
+func TestReporter_UserIDPerReport(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.SetUserID("primary")
+
+	r.NoError(t, reporter.ReportMessageWithContext("first", map[string]interface{}{UserIDContextKey: "user-a"}))
+	r.NoError(t, reporter.ReportMessageWithContext("second", map[string]interface{}{UserIDContextKey: "user-b", "key": "value"}))
+	r.NoError(t, reporter.ReportMessage("third"))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 3)
+
+	r.Equal(t, algo.HashHexSHA256("user-a"), events[0].Tags["UserID"])
+	r.Equal(t, algo.HashHexSHA256("user-b"), events[1].Tags["UserID"])
+	r.Equal(t, algo.HashHexSHA256("primary"), events[2].Tags["UserID"])
+
+	// The raw ID is not sent in the context.
+	r.NotContains(t, events[0].Contexts, "bridge")
+	r.Equal(t, sentry.Context{"key": "value"}, events[1].Contexts["bridge"])
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// sampledOutMessage carries the sampled-out count if no other report picked it up before Close.
+const sampledOutMessage = "Sampled out sentry reports"
+
+// sampler decides which reports are sent. Sampled-out reports are counted
+// and the count is attached to the next report which is sent, or sent on its own on Close.
+type sampler struct {
+	lock sync.Mutex
+	rng  *rand.Rand
+}
+
+func newSampler(seed int64) *sampler {
+	return &sampler{rng: rand.New(rand.NewSource(seed))} //nolint:gosec
+}
+
+func (s *sampler) reseed(seed int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rng.Seed(seed)
+}
+
+// sample reports whether an event should be sent with the given rate.
+func (s *sampler) sample(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+
+	if rate <= 0 {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.rng.Float64() < rate
+}
+
+// SetMessageSampleRate sets the fraction of messages which are sent, between 0 and 1. Defaults to 1.
+func (r *Reporter) SetMessageSampleRate(rate float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.messageSampleRate = rate
+}
+
+// SetExceptionSampleRate sets the fraction of exceptions which are sent, between 0 and 1. Defaults to 1.
+func (r *Reporter) SetExceptionSampleRate(rate float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.exceptionSampleRate = rate
+}
+
+func (r *Reporter) sampleMessage() bool {
+	r.lock.RLock()
+	rate := r.messageSampleRate
+	r.lock.RUnlock()
+
+	return r.sampleWithRate(rate)
+}
+
+func (r *Reporter) sampleException() bool {
+	r.lock.RLock()
+	rate := r.exceptionSampleRate
+	r.lock.RUnlock()
+
+	return r.sampleWithRate(rate)
+}
+
+func (r *Reporter) sampleWithRate(rate float64) bool {
+	if r.sampler.sample(rate) {
+		return true
+	}
+
+	r.sampledOutReports.Add(1)
+
+	return false
+}
+
+// reportSampledOut sends the pending sampled-out count, bypassing sampling and deduplication.
+func (r *Reporter) reportSampledOut() error {
+	if r.sampledOutReports.Load() == 0 {
+		return nil
+	}
+
+	return r.scopedReport(nil, false, sentry.LevelInfo, func(hub *sentry.Hub, scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelInfo)
+		scope.SetFingerprint([]string{"sampled-out"})
+		hub.CaptureMessage(sampledOutMessage)
+	})
+}
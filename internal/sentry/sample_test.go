@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestSampler_Ratio(t *testing.T) {
+	s := newSampler(1)
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		if s.sample(0.25) {
+			sampled++
+		}
+	}
+
+	r.InDelta(t, 2500, sampled, 250)
+	r.True(t, s.sample(1))
+	r.False(t, s.sample(0))
+}
+
+func TestSampler_Deterministic(t *testing.T) {
+	a, b := newSampler(42), newSampler(42)
+
+	for i := 0; i < 100; i++ {
+		r.Equal(t, a.sample(0.5), b.sample(0.5))
+	}
+}
+
+func TestReporter_MessageSampleRate(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.sampler = newSampler(1)
+	reporter.SetMessageSampleRate(0.1)
+
+	for i := 0; i < 1000; i++ {
+		r.NoError(t, reporter.ReportMessage(fmt.Sprintf("message %d", i)))
+	}
+
+	sent := len(reporter.CapturedEvents())
+	r.InDelta(t, 100, sent, 40)
+
+	// Exceptions are not affected by the message rate.
+	r.NoError(t, reporter.ReportException(errors.New("boom")))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, sent+1)
+
+	// Every sampled-out message is accounted for in the tags of the sent reports.
+	sampledOut := 0
+	for _, event := range events {
+		if count, ok := event.Tags["SampledOutReports"]; ok {
+			n, err := strconv.Atoi(count)
+			r.NoError(t, err)
+			sampledOut += n
+		}
+	}
+
+	r.Equal(t, 1000-sent, sampledOut)
+}
+
+func TestReporter_ExceptionSampleRate(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.sampler = newSampler(1)
+	reporter.SetExceptionSampleRate(0)
+
+	r.NoError(t, reporter.ReportException(errors.New("boom")))
+	r.Empty(t, reporter.CapturedEvents())
+
+	r.NoError(t, reporter.ReportMessage("message"))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 1)
+	r.Equal(t, "1", events[0].Tags["SampledOutReports"])
+}
+
+func TestReporter_CloseReportsSampledOut(t *testing.T) {
+	reporter := newDryRunReporter(t)
+	reporter.SetMessageSampleRate(0)
+
+	for i := 0; i < 3; i++ {
+		r.NoError(t, reporter.ReportMessage(fmt.Sprintf("message %d", i)))
+	}
+
+	r.Empty(t, reporter.CapturedEvents())
+	r.NoError(t, reporter.Close(context.Background()))
+
+	events := reporter.CapturedEvents()
+	r.Len(t, events, 1)
+	r.Equal(t, sampledOutMessage, events[0].Message)
+	r.Equal(t, "3", events[0].Tags["SampledOutReports"])
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchBufferSize    = 30
+	defaultBatchFlushInterval = 5 * time.Second
+)
+
+// BatchOptions configures background delivery of non-fatal events.
+type BatchOptions struct {
+	// BufferSize is the number of events kept in memory. Events reported while the buffer is full are dropped.
+	// Defaults to 30.
+	BufferSize int
+
+	// FlushInterval is how often buffered events are sent. Defaults to 5 seconds.
+	FlushInterval time.Duration
+}
+
+// EnableWithBatching initializes the sentry client like Enable, but messages are buffered
+// and sent in the background. Exceptions are still sent synchronously so crashes are not lost.
+func EnableWithBatching(batch BatchOptions) {
+	options := newClientOptions()
+	options.Transport = newSplitTransport(options.Transport, newBatchTransport(options.Transport, batch))
+
+	initClient(options)
+}
+
+// currentBatchTransport returns the batch transport of the current sentry client, if batching is enabled.
+func currentBatchTransport() *batchTransport {
+	return hubBatchTransport(sentry.CurrentHub())
+}
+
+// hubBatchTransport returns the batch transport of the hub's client, if batching is enabled.
+func hubBatchTransport(hub *sentry.Hub) *batchTransport {
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+
+	split, ok := client.Transport.(*splitTransport)
+	if !ok {
+		return nil
+	}
+
+	batch, _ := split.async.(*batchTransport)
+
+	return batch
+}
+
+// splitTransport sends events carrying an exception or a fatal level through the sync transport
+// and everything else through the async one.
+type splitTransport struct {
+	sync  sentry.Transport
+	async sentry.Transport
+}
+
+func newSplitTransport(syncTransport, asyncTransport sentry.Transport) *splitTransport {
+	return &splitTransport{sync: syncTransport, async: asyncTransport}
+}
+
+func (t *splitTransport) Configure(options sentry.ClientOptions) {
+	t.sync.Configure(options)
+	t.async.Configure(options)
+}
+
+func (t *splitTransport) SendEvent(event *sentry.Event) {
+	if sendsSync(len(event.Exception) > 0, event.Level) {
+		t.sync.SendEvent(event)
+	} else {
+		t.async.SendEvent(event)
+	}
+}
+
+// sendsSync reports whether splitTransport sends an event of this kind through the sync transport.
+func sendsSync(exception bool, level sentry.Level) bool {
+	return exception || level == sentry.LevelFatal
+}
+
+// reportFlusher returns how a report of this kind waits for its event to be delivered, or nil if it
+// should not wait because the event is sent in the background. With splitTransport only the sync side
+// is flushed, so exceptions do not wait for the buffered messages.
+func reportFlusher(hub *sentry.Hub, exception bool, level sentry.Level) func(time.Duration) bool {
+	if client := hub.Client(); client != nil {
+		if split, ok := client.Transport.(*splitTransport); ok {
+			if !sendsSync(exception, level) {
+				return nil
+			}
+
+			return split.sync.Flush
+		}
+	}
+
+	return hub.Flush
+}
+
+func (t *splitTransport) Flush(timeout time.Duration) bool {
+	// Both transports may share the same underlying one, so flush the async side first.
+	asyncOK := t.async.Flush(timeout)
+	syncOK := t.sync.Flush(timeout)
+
+	return asyncOK && syncOK
+}
+
+// batchTransport buffers events in memory and sends them through the wrapped transport every flush interval.
+type batchTransport struct {
+	sentry.Transport
+
+	options BatchOptions
+
+	lock    sync.Mutex
+	pending []*sentry.Event
+
+	sendLock sync.Mutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newBatchTransport(transport sentry.Transport, options BatchOptions) *batchTransport {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultBatchBufferSize
+	}
+
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultBatchFlushInterval
+	}
+
+	t := &batchTransport{
+		Transport: transport,
+		options:   options,
+		stopCh:    make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t
+}
+
+func (t *batchTransport) SendEvent(event *sentry.Event) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.pending) >= t.options.BufferSize {
+		logrus.WithField("event", event.EventID).Warn("Sentry event buffer is full, dropping event")
+		return
+	}
+
+	t.pending = append(t.pending, event)
+}
+
+// Flush sends the buffered events and waits for the wrapped transport to deliver them.
+func (t *batchTransport) Flush(timeout time.Duration) bool {
+	t.send()
+
+	return t.Transport.Flush(timeout)
+}
+
+func (t *batchTransport) run() {
+	ticker := time.NewTicker(t.options.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.send()
+
+		case <-t.stopCh:
+			return
+		}
+	}
+}
+
+// send hands the buffered events to the wrapped transport.
+func (t *batchTransport) send() {
+	t.sendLock.Lock()
+	defer t.sendLock.Unlock()
+
+	t.lock.Lock()
+	events := t.pending
+	t.pending = nil
+	t.lock.Unlock()
+
+	for _, event := range events {
+		t.Transport.SendEvent(event)
+	}
+}
+
+// stop ends the background delivery. Buffered events are kept until the next Flush.
+func (t *batchTransport) stop() {
+	t.stopOnce.Do(func() { close(t.stopCh) })
+}
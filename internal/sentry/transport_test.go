@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestSplitTransport(t *testing.T) {
+	syncTransport, asyncTransport := &memoryTransport{}, &memoryTransport{}
+	transport := newSplitTransport(syncTransport, asyncTransport)
+
+	transport.SendEvent(&sentry.Event{Message: "message", Level: sentry.LevelInfo})
+	transport.SendEvent(&sentry.Event{Message: "fatal", Level: sentry.LevelFatal})
+	transport.SendEvent(&sentry.Event{Exception: []sentry.Exception{{Value: "crash"}}, Level: sentry.LevelError})
+
+	r.Len(t, asyncTransport.capturedEvents(), 1)
+	r.Equal(t, "message", asyncTransport.capturedEvents()[0].Message)
+	r.Len(t, syncTransport.capturedEvents(), 2)
+}
+
+func TestBatchTransport_FlushInterval(t *testing.T) {
+	inner := &memoryTransport{}
+	transport := newBatchTransport(inner, BatchOptions{BufferSize: 10, FlushInterval: 10 * time.Millisecond})
+	defer transport.stop()
+
+	transport.SendEvent(&sentry.Event{Message: "one"})
+	transport.SendEvent(&sentry.Event{Message: "two"})
+
+	r.Eventually(t, func() bool { return len(inner.capturedEvents()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBatchTransport_Flush(t *testing.T) {
+	inner := &memoryTransport{}
+	transport := newBatchTransport(inner, BatchOptions{BufferSize: 10, FlushInterval: time.Hour})
+	defer transport.stop()
+
+	transport.SendEvent(&sentry.Event{Message: "one"})
+	r.Empty(t, inner.capturedEvents())
+
+	r.True(t, transport.Flush(time.Second))
+	r.Len(t, inner.capturedEvents(), 1)
+}
+
+func TestBatchTransport_DropsWhenFull(t *testing.T) {
+	inner := &memoryTransport{}
+	transport := newBatchTransport(inner, BatchOptions{BufferSize: 2, FlushInterval: time.Hour})
+	defer transport.stop()
+
+	for i := 0; i < 5; i++ {
+		transport.SendEvent(&sentry.Event{})
+	}
+
+	r.True(t, transport.Flush(time.Second))
+	r.Len(t, inner.capturedEvents(), 2)
+}
+
+func TestBatchTransport_Defaults(t *testing.T) {
+	transport := newBatchTransport(&memoryTransport{}, BatchOptions{})
+	defer transport.stop()
+
+	r.Equal(t, defaultBatchBufferSize, transport.options.BufferSize)
+	r.Equal(t, defaultBatchFlushInterval, transport.options.FlushInterval)
+}
+
+func TestReporter_CloseStopsBatching(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	inner := &memoryTransport{}
+	batch := newBatchTransport(inner, BatchOptions{FlushInterval: time.Hour})
+
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: newSplitTransport(&memoryTransport{}, batch)})
+	r.NoError(t, err)
+	reporter.dryRun = &dryRunSink{hub: sentry.NewHub(client, sentry.NewScope()), transport: inner}
+
+	r.NoError(t, reporter.ReportMessage("message"))
+	r.NoError(t, reporter.Close(context.Background()))
+
+	// The buffered message is delivered and the background delivery is stopped.
+	r.Len(t, inner.capturedEvents(), 1)
+
+	select {
+	case <-batch.stopCh:
+	default:
+		r.Fail(t, "batch transport was not stopped")
+	}
+}
+
+func TestReporter_MessagesAreNotFlushedWithBatching(t *testing.T) {
+	reporter := newDryRunReporter(t)
+
+	syncTransport, inner := &memoryTransport{}, &memoryTransport{}
+	batch := newBatchTransport(inner, BatchOptions{FlushInterval: time.Hour})
+	defer batch.stop()
+
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: newSplitTransport(syncTransport, batch)})
+	r.NoError(t, err)
+	reporter.dryRun = &dryRunSink{hub: sentry.NewHub(client, sentry.NewScope()), transport: syncTransport}
+
+	// The message returns while still buffered; the report does not flush it.
+	r.NoError(t, reporter.ReportMessage("message"))
+	r.Empty(t, inner.capturedEvents())
+
+	// Exceptions are still sent before the report returns.
+	r.NoError(t, reporter.ReportException("boom"))
+	r.Len(t, syncTransport.capturedEvents(), 1)
+	r.Empty(t, inner.capturedEvents())
+
+	r.True(t, batch.Flush(time.Second))
+	r.Len(t, inner.capturedEvents(), 1)
+}